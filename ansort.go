@@ -29,7 +29,6 @@ import (
 	"errors"
 	"fmt"
 	"sort"
-	"strconv"
 	"strings"
 	"unicode"
 )
@@ -55,6 +54,23 @@ type Config struct {
 	// CaseSensitive determines whether alphabetic comparisons are case-sensitive
 	// Default: true (case-sensitive)
 	CaseSensitive bool
+	// DecimalNumbers enables recognition of decimal numbers such as "3.14",
+	// which are then compared as real fractions instead of version segments
+	// Default: false (version-style behavior)
+	DecimalNumbers bool
+	// DecimalSeparator is the radix character used when DecimalNumbers is enabled
+	// Must be '.' or ','. Default: '.'
+	DecimalSeparator rune
+	// SignMode determines when a leading '-' or '+' is treated as the sign of a number
+	// Default: SignNone (signs are ordinary text)
+	SignMode SignMode
+	// CaseTieBreak orders strings that are otherwise equal (e.g. "File1" and "file1"
+	// when case-insensitive) by their original bytes, giving a total order
+	// Default: false
+	CaseTieBreak bool
+	// Strategy pins how sorts tokenize and compare strings
+	// Default: StrategyAuto (chosen from a sample of the data)
+	Strategy SortStrategy
 }
 
 // ExternalSortKeyConfig holds configuration options for external sort key generation
@@ -65,6 +81,40 @@ type ExternalSortKeyConfig struct {
 	// MaxNumericLength is the maximum length to pad numeric segments
 	// Default: 10 (supports numbers up to 9,999,999,999)
 	MaxNumericLength int
+	// DecimalNumbers enables recognition of decimal numbers such as "3.14"
+	// Default: false (version-style behavior)
+	DecimalNumbers bool
+	// DecimalSeparator is the radix character used when DecimalNumbers is enabled
+	// Must be '.' or ','. Default: '.'
+	DecimalSeparator rune
+	// SignMode determines when a leading '-' or '+' is treated as the sign of a number
+	// Default: SignNone (signs are ordinary text)
+	SignMode SignMode
+	// KeyScheme selects the encoding used for generated sort keys
+	// Default: KeySchemePadded (zero-padded numbers, compatible with existing stored keys)
+	KeyScheme KeyScheme
+	// Lossless appends a trailing section that records the original input, so
+	// that FromNaturalSortKey can decode the key. Lossless keys always use
+	// KeySchemeOrdered.
+	// Default: false
+	Lossless bool
+	// CaseTieBreak orders keys of inputs that differ only in case by their
+	// original bytes, matching Compare with WithCaseTieBreak. Like Lossless,
+	// it generates case-insensitive keys with KeySchemeOrdered.
+	// Default: false
+	CaseTieBreak bool
+	// Descending produces keys whose ascending lexicographic order is the
+	// reverse natural order
+	// Default: false
+	Descending bool
+	// Header prefixes every key with a self-describing header that records
+	// the key scheme and its parameters (see ParseSortKeyHeader)
+	// Default: false
+	Header bool
+	// MaxKeyBytes truncates keys to at most this many bytes without
+	// reordering them (see WithMaxKeyBytes)
+	// Default: 0 (unlimited)
+	MaxKeyBytes int
 }
 
 // DefaultExternalSortKeyConfig returns an ExternalSortKeyConfig with default settings
@@ -72,6 +122,7 @@ func DefaultExternalSortKeyConfig() ExternalSortKeyConfig {
 	return ExternalSortKeyConfig{
 		CaseSensitive:    true,
 		MaxNumericLength: 10,
+		DecimalSeparator: '.',
 	}
 }
 
@@ -120,12 +171,66 @@ func WithExternalCaseInsensitive() ExternalSortKeyOption {
 	return WithExternalCaseSensitive(false)
 }
 
+// WithExternalDecimalNumbers enables decimal number recognition for external sort key generation.
+// The separator must be '.' or ','. Fractional digits are padded to MaxNumericLength so
+// that keys preserve the same order as Compare with WithDecimalNumbers.
+// This is the external equivalent of WithDecimalNumbers() for direct sorting.
+//
+// Example:
+//
+//	sortKey := ansort.ToNaturalSortKey("price2.5", ansort.WithMaxNumericLength(3),
+//		ansort.WithExternalDecimalNumbers('.'))
+//	// Result: "price002.500"
+func WithExternalDecimalNumbers(separator rune) ExternalSortKeyOption {
+	return func(c *ExternalSortKeyConfig) {
+		c.DecimalNumbers = true
+		c.DecimalSeparator = separator
+	}
+}
+
+// WithExternalSignedNumbers enables signed number recognition for external sort key generation.
+// Negative numbers are encoded with a '-' prefix followed by complemented digits, so that
+// keys order negative values below zero and larger magnitudes first.
+// This is the external equivalent of WithSignedNumbers() for direct sorting.
+//
+// Example:
+//
+//	sortKey := ansort.ToNaturalSortKey("offset-10", ansort.WithMaxNumericLength(3),
+//		ansort.WithExternalSignedNumbers(ansort.SignAlways))
+//	// Result: "offset-989"
+func WithExternalSignedNumbers(mode SignMode) ExternalSortKeyOption {
+	return func(c *ExternalSortKeyConfig) {
+		c.SignMode = mode
+	}
+}
+
+// WithExternalCaseTieBreak adds a case tie-break level to case-insensitive external
+// sort keys. Keys of inputs that differ only in case are ordered by the original
+// input, matching Compare with WithCaseInsensitive and WithCaseTieBreak. The key
+// carries the original input in the same trailing section as WithLosslessKey,
+// so it is likewise generated with KeySchemeOrdered whatever WithKeyScheme
+// selects. This is the external equivalent of WithCaseTieBreak() for direct sorting.
+//
+// Example:
+//
+//	sortKey := ansort.ToNaturalSortKey("File1", ansort.WithExternalCaseInsensitive(),
+//		ansort.WithExternalCaseTieBreak())
+//	// Result: "2file!\"111111 File1!\""
+func WithExternalCaseTieBreak() ExternalSortKeyOption {
+	return func(c *ExternalSortKeyConfig) {
+		c.CaseTieBreak = true
+	}
+}
+
 // ErrInvalidConfig is returned when configuration validation fails
 var ErrInvalidConfig = errors.New("invalid configuration")
 
 // ErrNilInput is returned when a nil input is provided where non-nil is expected
 var ErrNilInput = errors.New("nil input provided")
 
+// ErrInvalidSortKey is returned when a sort key cannot be decoded
+var ErrInvalidSortKey = errors.New("invalid sort key")
+
 // ValidationError represents a configuration validation error
 type ValidationError struct {
 	Field   string
@@ -139,8 +244,17 @@ func (e ValidationError) Error() string {
 // validateConfig validates the configuration options
 // Returns an error if the configuration is invalid
 func validateConfig(config Config) error {
-	// Currently all Config fields are valid by design (bool can't be invalid)
-	// This function provides a framework for future validation needs
+	if err := validateSignMode(config.SignMode); err != nil {
+		return err
+	}
+	if err := validateSortStrategy(config.Strategy); err != nil {
+		return err
+	}
+	if config.DecimalNumbers {
+		if err := validateDecimalSeparator(config.DecimalSeparator); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -159,6 +273,20 @@ func validateExternalSortKeyConfig(config ExternalSortKeyConfig) error {
 			Message: "must be 50 or less to prevent excessive memory usage",
 		}
 	}
+	if err := validateSignMode(config.SignMode); err != nil {
+		return err
+	}
+	if config.DecimalNumbers {
+		if err := validateDecimalSeparator(config.DecimalSeparator); err != nil {
+			return err
+		}
+	}
+	if err := validateKeyScheme(config.KeyScheme); err != nil {
+		return err
+	}
+	if err := validateMaxKeyBytes(config); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -202,6 +330,63 @@ func WithCaseInsensitive() Option {
 	return WithCaseSensitive(false)
 }
 
+// WithDecimalNumbers enables decimal number recognition for direct natural sorting.
+// Numbers containing the separator ('.' or ',') are compared as real fractions,
+// so "price2.5" sorts before "price2.10". Without this option the separator is
+// treated as text, which gives version-style ordering ("v1.2" before "v1.10").
+//
+// Example:
+//
+//	data := []string{"price2.10", "price2.5", "price10"}
+//	ansort.SortStrings(data, ansort.WithDecimalNumbers('.'))
+//	// Result: ["price2.10", "price2.5", "price10"]
+func WithDecimalNumbers(separator rune) Option {
+	return func(c *Config) {
+		c.DecimalNumbers = true
+		c.DecimalSeparator = separator
+	}
+}
+
+// WithSignedNumbers enables signed number recognition for direct natural sorting.
+// A '-' or '+' directly before a number becomes its sign according to the mode,
+// so negative values sort below zero and larger magnitudes sort first.
+//
+// Example:
+//
+//	data := []string{"offset5", "offset-2", "offset0", "offset-10"}
+//	ansort.SortStrings(data, ansort.WithSignedNumbers(ansort.SignAlways))
+//	// Result: ["offset-10", "offset-2", "offset0", "offset5"]
+func WithSignedNumbers(mode SignMode) Option {
+	return func(c *Config) {
+		c.SignMode = mode
+	}
+}
+
+// WithCaseTieBreak adds a secondary comparison level for strings that compare
+// as equal, typically because they differ only in case under WithCaseInsensitive.
+// Such strings are ordered by their original bytes, so uppercase ASCII letters
+// come first and the result is a deterministic total order. Use the matching
+// WithExternalCaseTieBreak so that external systems return rows in the same order.
+//
+// Example:
+//
+//	data := []string{"file1", "File1", "FILE1"}
+//	ansort.SortStrings(data, ansort.WithCaseInsensitive(), ansort.WithCaseTieBreak())
+//	// Result: ["FILE1", "File1", "file1"]
+func WithCaseTieBreak() Option {
+	return func(c *Config) {
+		c.CaseTieBreak = true
+	}
+}
+
+// withConfig replaces the whole configuration, used internally to forward
+// a pre-built configuration through option-based APIs
+func withConfig(config Config) Option {
+	return func(c *Config) {
+		*c = config
+	}
+}
+
 // DefaultConfig returns a Config with default settings for direct natural sorting.
 // The default configuration uses case-sensitive comparison.
 //
@@ -209,7 +394,8 @@ func WithCaseInsensitive() Option {
 // where you need to inspect or modify the default configuration.
 func DefaultConfig() Config {
 	return Config{
-		CaseSensitive: true,
+		CaseSensitive:    true,
+		DecimalSeparator: '.',
 	}
 }
 
@@ -231,6 +417,18 @@ func buildExternalSortKeyConfig(options ...ExternalSortKeyOption) ExternalSortKe
 	return config
 }
 
+// compareConfig returns the direct sorting configuration whose Compare order
+// the external sort keys generated with this configuration reproduce
+func (c ExternalSortKeyConfig) compareConfig() Config {
+	return Config{
+		CaseSensitive:    c.CaseSensitive,
+		DecimalNumbers:   c.DecimalNumbers,
+		DecimalSeparator: c.DecimalSeparator,
+		SignMode:         c.SignMode,
+		CaseTieBreak:     c.CaseTieBreak,
+	}
+}
+
 // NewSorter creates a new AlphanumericSorter that implements sort.Interface for natural sorting.
 // The sorter can be used with Go's standard sort package functions like sort.Sort().
 //
@@ -303,7 +501,7 @@ func (s AlphanumericSorter) Swap(i, j int) {
 // the element with index j using natural alphanumeric comparison.
 // This method implements the sort.Interface.
 func (s AlphanumericSorter) Less(i, j int) bool {
-	return Compare(s.data[i], s.data[j], WithCaseSensitive(s.config.CaseSensitive)) < 0
+	return Compare(s.data[i], s.data[j], withConfig(s.config)) < 0
 }
 
 // Compare compares two strings using natural alphanumeric sorting rules.
@@ -363,26 +561,7 @@ func CompareValidated(a, b string, options ...Option) (int, error) {
 	tokensB := parseString(b)
 
 	// Compare token by token
-	minLen := len(tokensA)
-	if len(tokensB) < minLen {
-		minLen = len(tokensB)
-	}
-
-	for i := 0; i < minLen; i++ {
-		result := compareTokensWithConfig(tokensA[i], tokensB[i], config)
-		if result != 0 {
-			return result, nil
-		}
-	}
-
-	// If all compared tokens are equal, the shorter string comes first
-	if len(tokensA) < len(tokensB) {
-		return -1, nil
-	} else if len(tokensA) > len(tokensB) {
-		return 1, nil
-	}
-
-	return 0, nil
+	return compareParsedStrings(a, b, tokensA, tokensB, config), nil
 }
 
 // SortStrings sorts a slice of strings using natural alphanumeric ordering.
@@ -439,40 +618,55 @@ func SortStringsValidated(data []string, options ...Option) error {
 // parseString tokenizes a string into alternating alphabetic and numeric segments.
 // It separates the input string into tokens where each token is either purely
 // alphabetic or purely numeric characters.
+//
+// Any Unicode decimal digit (category Nd) is treated as numeric, so strings
+// written with Arabic-Indic, Devanagari or fullwidth digits tokenize the same
+// way as ASCII digits. All comparison paths share this tokenizer so that
+// Compare, CompareLegacy and the cached sorters always agree.
 func parseString(s string) []Token {
-	if len(s) == 0 {
-		return []Token{}
-	}
+	return parseStringOptimized(s)
+}
 
-	var tokens []Token
-	runes := []rune(s)
+// digitValue returns the decimal value of a Unicode digit rune, or -1 if the
+// rune is not a decimal digit. Unicode guarantees that every decimal digit
+// belongs to a contiguous run of ten code points ordered from zero to nine.
+func digitValue(r rune) int {
+	if r >= '0' && r <= '9' {
+		return int(r - '0')
+	}
+	if !unicode.IsDigit(r) {
+		return -1
+	}
+	for _, rng := range unicode.Nd.R16 {
+		if r >= rune(rng.Lo) && r <= rune(rng.Hi) {
+			return int(r-rune(rng.Lo)) % 10
+		}
+	}
+	for _, rng := range unicode.Nd.R32 {
+		if r >= rune(rng.Lo) && r <= rune(rng.Hi) {
+			return int(r-rune(rng.Lo)) % 10
+		}
+	}
+	return -1
+}
 
-	for i := 0; i < len(runes); {
-		start := i
+// normalizeDigits rewrites every Unicode decimal digit in s as its ASCII
+// equivalent. ASCII strings are returned unchanged without allocating.
+func normalizeDigits(s string) string {
+	if isASCII(s) {
+		return s
+	}
 
-		// Check if current character is a digit
-		if unicode.IsDigit(runes[i]) {
-			// Collect all consecutive digits
-			for i < len(runes) && unicode.IsDigit(runes[i]) {
-				i++
-			}
-			tokens = append(tokens, Token{
-				Type:  NumericToken,
-				Value: string(runes[start:i]),
-			})
+	var result strings.Builder
+	result.Grow(len(s))
+	for _, r := range s {
+		if v := digitValue(r); v >= 0 {
+			result.WriteByte(byte('0' + v))
 		} else {
-			// Collect all consecutive non-digits
-			for i < len(runes) && !unicode.IsDigit(runes[i]) {
-				i++
-			}
-			tokens = append(tokens, Token{
-				Type:  AlphaToken,
-				Value: string(runes[start:i]),
-			})
+			result.WriteRune(r)
 		}
 	}
-
-	return tokens
+	return result.String()
 }
 
 // compareTokens compares two tokens according to their types and values.
@@ -499,45 +693,12 @@ func compareTokensWithConfig(a, b Token, config Config) int {
 
 	// Both tokens are the same type
 	if a.Type == NumericToken {
-		// Compare numerically - convert to integers
-		aNum, aErr := strconv.Atoi(a.Value)
-		bNum, bErr := strconv.Atoi(b.Value)
-
-		// If either conversion fails, fall back to string comparison
-		if aErr != nil || bErr != nil {
-			if a.Value < b.Value {
-				return -1
-			} else if a.Value > b.Value {
-				return 1
-			}
-			return 0
-		}
-
-		// Compare as integers
-		if aNum < bNum {
-			return -1
-		} else if aNum > bNum {
-			return 1
+		// Compare numerically without converting to a fixed-width integer so
+		// that numbers of any length keep their magnitude ordering
+		if format := config.numberFormat(); format != (numberFormat{}) {
+			return compareFormattedNumbers(a.Value, b.Value, format)
 		}
-
-		// Phase 2.2: Leading Zero Handling
-		// When numeric values are equal, use string comparison as tie-breaker
-		// This means "1" comes before "001" (shorter first)
-		if a.Value != b.Value {
-			if len(a.Value) < len(b.Value) {
-				return -1
-			} else if len(a.Value) > len(b.Value) {
-				return 1
-			}
-			// If same length but different strings, use lexicographic order
-			if a.Value < b.Value {
-				return -1
-			} else if a.Value > b.Value {
-				return 1
-			}
-		}
-
-		return 0
+		return compareNumericValues(a.Value, b.Value)
 	} else {
 		// Both are alphabetic - compare based on case sensitivity setting
 		aValue := a.Value
@@ -557,6 +718,109 @@ func compareTokensWithConfig(a, b Token, config Config) int {
 	}
 }
 
+// compareParsedStrings compares two strings from their tokenizations.
+// When CaseTieBreak is enabled, strings that compare as equal but differ
+// (such as "File1" and "file1" when case-insensitive) are ordered by their
+// original bytes, giving a deterministic total order.
+func compareParsedStrings(a, b string, tokensA, tokensB []Token, config Config) int {
+	result := compareTokenSlices(tokensA, tokensB, config)
+	if result == 0 && config.CaseTieBreak {
+		return strings.Compare(a, b)
+	}
+	return result
+}
+
+// compareTokenSlices compares two tokenized strings token by token using the
+// specified configuration. Tokens are first refined according to the configured
+// number format, so callers can share caches of default tokenizations.
+// If all compared tokens are equal, the string with fewer tokens comes first.
+func compareTokenSlices(tokensA, tokensB []Token, config Config) int {
+	format := config.numberFormat()
+	tokensA = refineTokens(tokensA, format)
+	tokensB = refineTokens(tokensB, format)
+
+	minLen := len(tokensA)
+	if len(tokensB) < minLen {
+		minLen = len(tokensB)
+	}
+
+	for i := 0; i < minLen; i++ {
+		result := compareTokensWithConfig(tokensA[i], tokensB[i], config)
+		if result != 0 {
+			return result
+		}
+	}
+
+	// If all compared tokens are equal, the shorter string comes first
+	if len(tokensA) < len(tokensB) {
+		return -1
+	} else if len(tokensA) > len(tokensB) {
+		return 1
+	}
+
+	return 0
+}
+
+// compareNumericValues compares two digit strings by numeric magnitude.
+// Leading zeros are stripped, the remaining digits are compared by length and
+// then digit by digit, so values of arbitrary length are ordered correctly.
+// When both values are numerically equal, the shorter original string comes
+// first ("1" before "001"), falling back to lexicographic order.
+func compareNumericValues(a, b string) int {
+	// Map Unicode digits to ASCII so that mixed scripts compare by value
+	aNorm := normalizeDigits(a)
+	bNorm := normalizeDigits(b)
+
+	if result := compareIntegerDigits(aNorm, bNorm); result != 0 {
+		return result
+	}
+	return compareNumericTieBreak(a, b, aNorm, bNorm)
+}
+
+// compareIntegerDigits compares two ASCII digit strings by magnitude,
+// ignoring leading zeros.
+func compareIntegerDigits(a, b string) int {
+	aDigits := strings.TrimLeft(a, "0")
+	bDigits := strings.TrimLeft(b, "0")
+
+	// More significant digits means a larger number
+	if len(aDigits) < len(bDigits) {
+		return -1
+	} else if len(aDigits) > len(bDigits) {
+		return 1
+	}
+
+	// Same number of significant digits - compare digit by digit
+	for i := 0; i < len(aDigits); i++ {
+		if aDigits[i] < bDigits[i] {
+			return -1
+		} else if aDigits[i] > bDigits[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// compareNumericTieBreak orders two numerically equal values.
+// The original strings a and b are used for the final lexicographic
+// fallback, while aNorm and bNorm are their ASCII-normalized forms.
+func compareNumericTieBreak(a, b, aNorm, bNorm string) int {
+	// Phase 2.2: Leading Zero Handling
+	// When numeric values are equal, use string comparison as tie-breaker
+	// This means "1" comes before "001" (shorter first)
+	if len(aNorm) < len(bNorm) {
+		return -1
+	} else if len(aNorm) > len(bNorm) {
+		return 1
+	}
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
 // ToNaturalSortKey generates a lexicographically sortable string from an alphanumeric input
 // that maintains natural alphanumeric ordering when sorted by external systems.
 // This is the main function for external system integration.
@@ -592,10 +856,6 @@ func compareTokensWithConfig(a, b Token, config Config) int {
 //	// 3. Query ordered by sortKey
 //	// 4. Results maintain natural order
 func ToNaturalSortKey(input string, options ...ExternalSortKeyOption) string {
-	if input == "" {
-		return ""
-	}
-
 	// Build configuration from options
 	config := buildExternalSortKeyConfig(options...)
 
@@ -620,10 +880,6 @@ func ToNaturalSortKey(input string, options ...ExternalSortKeyOption) string {
 //		log.Fatal(err) // Will fail due to excessive padding length
 //	}
 func ToNaturalSortKeyValidated(input string, options ...ExternalSortKeyOption) (string, error) {
-	if input == "" {
-		return "", nil
-	}
-
 	// Build and validate configuration from options
 	config := buildExternalSortKeyConfig(options...)
 	if err := validateExternalSortKeyConfig(config); err != nil {
@@ -671,11 +927,6 @@ func ToNaturalSortKeys(inputs []string, options ...ExternalSortKeyOption) []stri
 
 	// Process each input with the shared configuration
 	for i, input := range inputs {
-		if input == "" {
-			results[i] = ""
-			continue
-		}
-
 		// Reuse existing tokenization and processing logic
 		results[i] = generateSortKeyWithConfig(input, config)
 	}
@@ -718,11 +969,6 @@ func ToNaturalSortKeysValidated(inputs []string, options ...ExternalSortKeyOptio
 
 	// Process each input with the validated shared configuration
 	for i, input := range inputs {
-		if input == "" {
-			results[i] = ""
-			continue
-		}
-
 		// Reuse existing tokenization and processing logic
 		results[i] = generateSortKeyWithConfig(input, config)
 	}
@@ -734,10 +980,52 @@ func ToNaturalSortKeysValidated(inputs []string, options ...ExternalSortKeyOptio
 // for a given input using a pre-built configuration. This function centralizes the
 // core sort key generation logic to avoid code duplication between single and batch functions.
 //
-// This function assumes the input is non-empty and the config is valid.
+// Empty input produces an empty key, except for descending keys where it must
+// sort after every other key. This function assumes the config is valid.
 func generateSortKeyWithConfig(input string, config ExternalSortKeyConfig) string {
+	return generateSortKeyResult(input, config).Key
+}
+
+// generateSortKeyResult generates a sort key like generateSortKeyWithConfig and
+// reports whether it was truncated to fit MaxKeyBytes.
+func generateSortKeyResult(input string, config ExternalSortKeyConfig) SortKeyResult {
+	var key string
+	if input != "" {
+		switch config.keyScheme() {
+		case KeySchemeOrdered:
+			key = generateOrderedSortKey(input, config)
+		case KeySchemeCompact:
+			key = generateCompactSortKey(input, config)
+		default:
+			key = generatePaddedSortKey(input, config)
+		}
+
+		// The lossless section also provides the case tie-break level, since it
+		// orders otherwise equal keys by the original input
+		if config.Lossless || (config.CaseTieBreak && !config.CaseSensitive) {
+			key = appendLosslessSection(key, input)
+		}
+	}
+
+	if config.Descending {
+		key = descendingKey(key)
+	}
+	if config.Header {
+		key = keyHeader(config) + key
+	}
+	if config.MaxKeyBytes > 0 {
+		key, truncated := truncateSortKey(key, config.MaxKeyBytes)
+		return SortKeyResult{Key: key, Truncated: truncated}
+	}
+	return SortKeyResult{Key: key}
+}
+
+// generatePaddedSortKey generates a KeySchemePadded sort key, padding numeric
+// segments with leading zeros to MaxNumericLength.
+func generatePaddedSortKey(input string, config ExternalSortKeyConfig) string {
 	// Tokenize the input string using existing parseString function
-	tokens := parseString(input)
+	format := config.numberFormat()
+	tokens := refineTokens(parseString(input), format)
 
 	// Estimate result size for better memory allocation
 	// Average estimation: input length + (number of numeric tokens * padding overhead)
@@ -753,9 +1041,12 @@ func generateSortKeyWithConfig(input string, config ExternalSortKeyConfig) strin
 	result.Grow(estimatedSize) // Pre-allocate for performance
 
 	for _, token := range tokens {
-		if token.Type == NumericToken {
-			// Pad numeric tokens with leading zeros
-			paddedNumber := padNumericToken(token.Value, config.MaxNumericLength)
+		if token.Type == NumericToken && format != (numberFormat{}) {
+			writeFormattedNumberKey(&result, token.Value, config)
+		} else if token.Type == NumericToken {
+			// Pad numeric tokens with leading zeros, normalizing Unicode digits
+			// so that keys from different scripts interleave correctly
+			paddedNumber := padNumericToken(normalizeDigits(token.Value), config.MaxNumericLength)
 			result.WriteString(paddedNumber)
 		} else {
 			// Handle alphabetic tokens based on case sensitivity
@@ -799,26 +1090,7 @@ func CompareLegacy(a, b string, options ...Option) int {
 	tokensB := parseString(b)
 
 	// Compare token by token
-	minLen := len(tokensA)
-	if len(tokensB) < minLen {
-		minLen = len(tokensB)
-	}
-
-	for i := 0; i < minLen; i++ {
-		result := compareTokensWithConfig(tokensA[i], tokensB[i], config)
-		if result != 0 {
-			return result
-		}
-	}
-
-	// If all compared tokens are equal, the shorter string comes first
-	if len(tokensA) < len(tokensB) {
-		return -1
-	} else if len(tokensA) > len(tokensB) {
-		return 1
-	}
-
-	return 0
+	return compareParsedStrings(a, b, tokensA, tokensB, config)
 }
 
 // SortStringsLegacy provides the original sorting implementation without optimizations.
@@ -850,7 +1122,19 @@ func SortStringsLegacy(data []string, options ...Option) {
 //	// Reduce cache size for memory-constrained environments
 //	ansort.ConfigureCacheSize(500)
 func ConfigureCacheSize(size int) {
-	globalCache = NewTokenCache(size)
+	defaultCollator.ConfigureCacheSize(size)
+}
+
+// ConfigureCache replaces the global token cache used by the optimized
+// operations with an empty cache of the given size and options. Unlike
+// ConfigureCacheSize, it can bound the cache's memory and shard it.
+//
+// Example:
+//
+//	// Bound memory for long paths and reduce lock contention
+//	ansort.ConfigureCache(100000, ansort.WithCacheMaxBytes(16<<20), ansort.WithCacheShards(16))
+func ConfigureCache(size int, options ...TokenCacheOption) {
+	defaultCollator.ConfigureCache(size, options...)
 }
 
 // DisableCache disables caching for all optimized operations,
@@ -862,7 +1146,7 @@ func ConfigureCacheSize(size int) {
 //	// Disable caching globally
 //	ansort.DisableCache()
 func DisableCache() {
-	globalCacheDisabled = true
+	defaultCollator.DisableCache()
 }
 
 // EnableCache re-enables caching for optimized operations.
@@ -873,12 +1157,9 @@ func DisableCache() {
 //	// Re-enable caching
 //	ansort.EnableCache()
 func EnableCache() {
-	globalCacheDisabled = false
+	defaultCollator.EnableCache()
 }
 
-// Internal flag to track if caching is disabled
-var globalCacheDisabled = false
-
 // CompareWithoutCache provides comparison without any caching overhead
 func CompareWithoutCache(a, b string, options ...Option) int {
 	return CompareLegacy(a, b, options...)
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 )
@@ -680,3 +681,131 @@ func TestMultiSegmentNumberSupport(t *testing.T) {
 		})
 	}
 }
+
+// TestArbitraryPrecisionNumbers verifies that numbers wider than int are compared by magnitude
+func TestArbitraryPrecisionNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected int
+	}{
+		{"twenty digits vs twenty one digits", "id99999999999999999999", "id100000000000000000000", -1},
+		{"huge vs small", "id99999999999999999999", "id5", 1},
+		{"same length huge numbers", "block123456789012345678901", "block123456789012345678902", -1},
+		{"leading zeros on huge number", "id000099999999999999999999", "id100000000000000000000", -1},
+		{"equal huge value shorter first", "id99999999999999999999", "id0099999999999999999999", -1},
+		{"equal huge values", "id99999999999999999999", "id99999999999999999999", 0},
+		{"all zeros", "v0", "v000", -1},
+	}
+
+	comparators := map[string]func(a, b string) int{
+		"Compare":       func(a, b string) int { return Compare(a, b) },
+		"CompareLegacy": func(a, b string) int { return CompareLegacy(a, b) },
+		"CompareValidated": func(a, b string) int {
+			result, err := CompareValidated(a, b)
+			if err != nil {
+				t.Fatalf("CompareValidated returned error: %v", err)
+			}
+			return result
+		},
+	}
+
+	for _, tt := range tests {
+		for name, compare := range comparators {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				if result := compare(tt.a, tt.b); result != tt.expected {
+					t.Errorf("%s(%q, %q) = %d, want %d", name, tt.a, tt.b, result, tt.expected)
+				}
+				if result := compare(tt.b, tt.a); result != -tt.expected {
+					t.Errorf("%s(%q, %q) = %d, want %d", name, tt.b, tt.a, result, -tt.expected)
+				}
+			})
+		}
+	}
+
+	t.Run("sorters agree", func(t *testing.T) {
+		input := []string{"id100000000000000000000", "id5", "id99999999999999999999", "id18446744073709551616"}
+		expected := []string{"id5", "id18446744073709551616", "id99999999999999999999", "id100000000000000000000"}
+
+		for name, sortFn := range map[string]func([]string){
+			"SortStrings":           func(d []string) { SortStrings(d) },
+			"SortStringsLegacy":     func(d []string) { SortStringsLegacy(d) },
+			"SortStringsPooled":     func(d []string) { SortStringsPooled(d) },
+			"CachedSorter":          func(d []string) { sort.Sort(NewCachedSorter(d)) },
+			"HighPerformanceSorter": func(d []string) { sort.Sort(NewHighPerformanceSorter(d)) },
+		} {
+			data := make([]string, len(input))
+			copy(data, input)
+			sortFn(data)
+			if !reflect.DeepEqual(data, expected) {
+				t.Errorf("%s: got %v, want %v", name, data, expected)
+			}
+		}
+	})
+}
+
+// TestUnicodeDigitSupport verifies that all Unicode decimal digits are compared numerically
+func TestUnicodeDigitSupport(t *testing.T) {
+	t.Run("digit values", func(t *testing.T) {
+		tests := []struct {
+			r     rune
+			value int
+		}{
+			{'7', 7},
+			{'٣', 3},  // Arabic-Indic three
+			{'९', 9},  // Devanagari nine
+			{'０', 0},  // Fullwidth zero
+			{'𝟠', 8},  // Mathematical double-struck eight
+			{'a', -1}, // Not a digit
+			{'Ⅻ', -1}, // Roman numeral (Nl, not Nd)
+		}
+		for _, tt := range tests {
+			if got := digitValue(tt.r); got != tt.value {
+				t.Errorf("digitValue(%q) = %d, want %d", tt.r, got, tt.value)
+			}
+		}
+	})
+
+	t.Run("tokenization", func(t *testing.T) {
+		expected := []Token{
+			{Type: AlphaToken, Value: "ファイル"},
+			{Type: NumericToken, Value: "１０"},
+			{Type: AlphaToken, Value: ".txt"},
+		}
+		input := "ファイル１０.txt"
+		if got := parseString(input); !reflect.DeepEqual(got, expected) {
+			t.Errorf("parseString(%q) = %v, want %v", input, got, expected)
+		}
+		if got := parseStringPooled(input); !reflect.DeepEqual(got, expected) {
+			t.Errorf("parseStringPooled(%q) = %v, want %v", input, got, expected)
+		}
+	})
+
+	t.Run("comparison", func(t *testing.T) {
+		tests := []struct {
+			a, b     string
+			expected int
+		}{
+			{"ファイル２", "ファイル１０", -1},
+			{"file٢", "file10", -1},  // Arabic-Indic two vs ASCII ten
+			{"page९", "page१०", -1},  // Devanagari nine vs ten
+			{"item12", "item１２", -1}, // Equal values tie-break deterministically
+			{"v٣", "v3", 1},
+		}
+		for _, tt := range tests {
+			if got := Compare(tt.a, tt.b); got != tt.expected {
+				t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+			}
+			if got := CompareLegacy(tt.a, tt.b); got != tt.expected {
+				t.Errorf("CompareLegacy(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+			}
+		}
+	})
+
+	t.Run("sort keys normalize digits", func(t *testing.T) {
+		key := ToNaturalSortKey("ファイル１０", WithMaxNumericLength(4))
+		if expected := "ファイル0010"; key != expected {
+			t.Errorf("ToNaturalSortKey() = %q, want %q", key, expected)
+		}
+	})
+}
@@ -0,0 +1,494 @@
+package ansort
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Binary sort key layout
+//
+// A binary key is a sequence of token encodings, optionally followed by a
+// trailing section holding the original input. Keys compare with bytes.Compare
+// in the same order as Compare with the equivalent options; inputs that Compare
+// reports as equal but differ (such as "File1" and "file1" when case-insensitive)
+// are ordered deterministically by the trailing section.
+//
+//   - Text tokens: binaryAlphaMarker, the (lower-cased) text with 0x00 bytes
+//     escaped as 0x00 0xFF, then the terminator 0x00 0x01.
+//   - Numeric tokens: binaryNumericMarker, a sign byte in signed mode, the
+//     magnitude (significant digit count and packed digits, then packed
+//     fractional digits in decimal mode, all complemented for negative values),
+//     the character count tie-break, and the original text when it cannot be
+//     rebuilt from the digits.
+//   - Trailing section: binaryEndMarker followed by the raw original input,
+//     present only when case folding changed the text or a case tie-break
+//     is requested.
+const (
+	binaryEndMarker      = 0x00
+	binaryNumericMarker  = 0x01
+	binaryAlphaMarker    = 0x02
+	binaryOriginalMarker = 0x03
+
+	binaryNegative = 0x01
+	binaryPositive = 0x02
+
+	binaryEscape        = 0x00
+	binaryEscapedZero   = 0xFF
+	binaryTerminatorEnd = 0x01
+)
+
+// tokenBufferSize is the number of tokens parsed on the stack before
+// the binary key encoder falls back to a heap allocation
+const tokenBufferSize = 16
+
+// AppendNaturalSortKey appends a binary sort key for input to dst and returns
+// the extended buffer. Binary keys compare with bytes.Compare in natural order,
+// making them suitable for embedded key-value stores such as Badger or Pebble.
+//
+// Numbers have no length limit and text may contain any bytes, so the keys
+// match Compare for every input. MaxNumericLength and KeyScheme do not apply
+// to binary keys. When dst has enough capacity and no options are given, no
+// memory is allocated; use AppendNaturalSortKeyWithConfig to avoid rebuilding
+// the configuration on every call when options are needed.
+//
+// Use DecodeNaturalSortKey with the same options to recover the original input.
+//
+// Example:
+//
+//	buf := make([]byte, 0, 64)
+//	for _, name := range names {
+//		buf = ansort.AppendNaturalSortKey(buf[:0], name)
+//		db.Set(buf, value)
+//	}
+func AppendNaturalSortKey(dst []byte, input string, options ...ExternalSortKeyOption) []byte {
+	if len(options) == 0 {
+		return appendBinarySortKey(dst, input, DefaultExternalSortKeyConfig())
+	}
+	config := buildExternalSortKeyConfig(options...)
+	return appendBinarySortKey(dst, input, config)
+}
+
+// AppendNaturalSortKeyWithConfig appends a binary sort key for input to dst
+// using a pre-built configuration. It behaves like AppendNaturalSortKey, but
+// the default and case-insensitive configurations never allocate when dst
+// has enough capacity, which suits tight loops over large datasets.
+//
+// Example:
+//
+//	config := ansort.DefaultExternalSortKeyConfig()
+//	config.CaseSensitive = false
+//	buf = ansort.AppendNaturalSortKeyWithConfig(buf[:0], name, config)
+func AppendNaturalSortKeyWithConfig(dst []byte, input string, config ExternalSortKeyConfig) []byte {
+	return appendBinarySortKey(dst, input, config)
+}
+
+// ToNaturalSortKeyBytes returns a binary sort key for input in a new buffer.
+// It is a convenience wrapper around AppendNaturalSortKey.
+//
+// Example:
+//
+//	key := ansort.ToNaturalSortKeyBytes("file10.txt")
+//	// bytes.Compare(key, ansort.ToNaturalSortKeyBytes("file9.txt")) == 1
+func ToNaturalSortKeyBytes(input string, options ...ExternalSortKeyOption) []byte {
+	return AppendNaturalSortKey(make([]byte, 0, len(input)+8), input, options...)
+}
+
+// DecodeNaturalSortKey recovers the original input from a binary sort key
+// produced by AppendNaturalSortKey or ToNaturalSortKeyBytes. The options must
+// match the ones used to generate the key.
+//
+// Returns ErrInvalidSortKey if the key is malformed.
+//
+// Example:
+//
+//	key := ansort.ToNaturalSortKeyBytes("file10.txt")
+//	original, err := ansort.DecodeNaturalSortKey(key)
+//	// original: "file10.txt"
+func DecodeNaturalSortKey(key []byte, options ...ExternalSortKeyOption) (string, error) {
+	config := buildExternalSortKeyConfig(options...)
+	if config.Descending {
+		ascending, err := ascendingKey(string(key))
+		if err != nil {
+			return "", err
+		}
+		key = []byte(ascending)
+	}
+	return decodeBinarySortKey(key, config)
+}
+
+// appendBinarySortKey is the configuration-based implementation of AppendNaturalSortKey
+func appendBinarySortKey(dst []byte, input string, config ExternalSortKeyConfig) []byte {
+	if config.MaxKeyBytes > 0 {
+		// Byte-wise truncation of the complete key never reorders binary keys
+		start := len(dst)
+		maxKeyBytes := config.MaxKeyBytes
+		config.MaxKeyBytes = 0
+		dst = appendBinarySortKey(dst, input, config)
+		if len(dst)-start > maxKeyBytes {
+			dst = dst[:start+maxKeyBytes]
+		}
+		return dst
+	}
+
+	if config.Descending {
+		// Encode ascending after the existing contents, then replace it
+		// with its descending form
+		start := len(dst)
+		config.Descending = false
+		dst = appendBinarySortKey(dst, input, config)
+		mid := len(dst)
+		dst = appendDescendingKey(dst, string(dst[start:mid]))
+		n := copy(dst[start:], dst[mid:])
+		return dst[:start+n]
+	}
+
+	format := config.numberFormat()
+
+	var buffer [tokenBufferSize]Token
+	var tokens []Token
+	if isASCII(input) {
+		tokens = parseStringASCII(input, buffer[:0])
+	} else {
+		tokens = parseStringUnicode(input, buffer[:0])
+	}
+	tokens = refineTokens(tokens, format)
+
+	caseFolded := false
+	for _, token := range tokens {
+		if token.Type == NumericToken {
+			dst = append(dst, binaryNumericMarker)
+			dst = appendBinaryNumber(dst, token.Value, format)
+			continue
+		}
+
+		dst = append(dst, binaryAlphaMarker)
+		if config.CaseSensitive {
+			dst = appendBinaryText(dst, token.Value)
+		} else {
+			dst = appendBinaryLower(dst, token.Value)
+			caseFolded = caseFolded || lowerChanges(token.Value)
+		}
+	}
+
+	// Keep the original input when case folding lost information, or to
+	// order inputs that differ only in case when a tie-break is requested
+	if caseFolded || (config.CaseTieBreak && !config.CaseSensitive) {
+		dst = append(dst, binaryEndMarker)
+		dst = append(dst, input...)
+	}
+
+	return dst
+}
+
+// appendBinaryNumber appends the binary encoding of a numeric token
+func appendBinaryNumber(dst []byte, value string, format numberFormat) []byte {
+	normalized := normalizeDigits(value)
+	negative, magnitude := splitSign(normalized)
+	if format.signMode == SignNone {
+		negative, magnitude = false, normalized
+	}
+	negative = negative && !isZeroMagnitude(magnitude, format.decimalSeparator)
+
+	if format.signMode != SignNone {
+		if negative {
+			dst = append(dst, binaryNegative)
+		} else {
+			dst = append(dst, binaryPositive)
+		}
+	}
+
+	integer, fraction := splitDecimal(magnitude, format.decimalSeparator)
+	integer = trimLeadingZeros(integer)
+
+	start := len(dst)
+	dst = appendOrderedUint(dst, uint64(len(integer)))
+	dst = appendPackedDigits(dst, integer)
+	if format.decimalSeparator != 0 {
+		dst = appendPackedFraction(dst, trimTrailingZeros(fraction))
+	}
+	if negative {
+		// Complement the magnitude so larger negative values sort first
+		for i := start; i < len(dst); i++ {
+			dst[i] = ^dst[i]
+		}
+	}
+
+	// Tie-break for numerically equal values, as in compareNumericTieBreak
+	dst = appendOrderedUint(dst, uint64(len(normalized)))
+	if format != (numberFormat{}) || !isASCII(value) {
+		dst = append(dst, binaryOriginalMarker)
+		dst = appendBinaryText(dst, value)
+	}
+
+	return dst
+}
+
+// appendOrderedUint appends n as a byte count followed by its minimal
+// big-endian bytes, so that larger values produce larger encodings
+func appendOrderedUint(dst []byte, n uint64) []byte {
+	size := 0
+	for v := n; v > 0; v >>= 8 {
+		size++
+	}
+	dst = append(dst, byte(size))
+	for i := size - 1; i >= 0; i-- {
+		dst = append(dst, byte(n>>(8*uint(i))))
+	}
+	return dst
+}
+
+// appendPackedDigits packs ASCII digits two per byte. The digit count is
+// encoded separately, so an odd final digit is padded with a zero nibble.
+func appendPackedDigits(dst []byte, digits string) []byte {
+	for i := 0; i < len(digits); i += 2 {
+		b := (digits[i] - '0') << 4
+		if i+1 < len(digits) {
+			b |= digits[i+1] - '0'
+		}
+		dst = append(dst, b)
+	}
+	return dst
+}
+
+// appendPackedFraction packs fractional digits as nibbles holding digit+1,
+// so a zero nibble marks the end and shorter fractions sort first
+func appendPackedFraction(dst []byte, digits string) []byte {
+	for i := 0; i < len(digits); i += 2 {
+		b := (digits[i] - '0' + 1) << 4
+		if i+1 < len(digits) {
+			b |= digits[i+1] - '0' + 1
+		}
+		dst = append(dst, b)
+	}
+	if len(digits)%2 == 0 {
+		dst = append(dst, 0)
+	}
+	return dst
+}
+
+// appendBinaryText appends text with 0x00 bytes escaped, followed by the terminator
+func appendBinaryText(dst []byte, text string) []byte {
+	for i := 0; i < len(text); i++ {
+		if text[i] == binaryEscape {
+			dst = append(dst, binaryEscape, binaryEscapedZero)
+		} else {
+			dst = append(dst, text[i])
+		}
+	}
+	return append(dst, binaryEscape, binaryTerminatorEnd)
+}
+
+// appendBinaryLower appends the lower-cased text like appendBinaryText.
+// Lower-casing matches strings.ToLower without allocating.
+func appendBinaryLower(dst []byte, text string) []byte {
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			c := byte(r)
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			if c == binaryEscape {
+				dst = append(dst, binaryEscape, binaryEscapedZero)
+			} else {
+				dst = append(dst, c)
+			}
+			continue
+		}
+		dst = utf8.AppendRune(dst, unicode.ToLower(r))
+	}
+	return append(dst, binaryEscape, binaryTerminatorEnd)
+}
+
+// lowerChanges reports whether lower-casing text would change it
+func lowerChanges(text string) bool {
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.ToLower(r) != r || (r == utf8.RuneError && size == 1) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// trimLeadingZeros removes leading '0' characters without allocating
+func trimLeadingZeros(digits string) string {
+	i := 0
+	for i < len(digits) && digits[i] == '0' {
+		i++
+	}
+	return digits[i:]
+}
+
+// trimTrailingZeros removes trailing '0' characters without allocating
+func trimTrailingZeros(digits string) string {
+	i := len(digits)
+	for i > 0 && digits[i-1] == '0' {
+		i--
+	}
+	return digits[:i]
+}
+
+// binaryKeyReader reads the components of a binary sort key
+type binaryKeyReader struct {
+	key []byte
+	pos int
+}
+
+// readByte reads a single byte, complemented when invert is set
+func (r *binaryKeyReader) readByte(invert bool) (byte, error) {
+	if r.pos >= len(r.key) {
+		return 0, ErrInvalidSortKey
+	}
+	b := r.key[r.pos]
+	r.pos++
+	if invert {
+		b = ^b
+	}
+	return b, nil
+}
+
+// readUint reads a value written by appendOrderedUint
+func (r *binaryKeyReader) readUint(invert bool) (uint64, error) {
+	size, err := r.readByte(invert)
+	if err != nil || size > 8 {
+		return 0, ErrInvalidSortKey
+	}
+	var n uint64
+	for i := 0; i < int(size); i++ {
+		b, err := r.readByte(invert)
+		if err != nil {
+			return 0, err
+		}
+		n = n<<8 | uint64(b)
+	}
+	return n, nil
+}
+
+// readText reads text written by appendBinaryText
+func (r *binaryKeyReader) readText(dst []byte) ([]byte, error) {
+	for {
+		b, err := r.readByte(false)
+		if err != nil {
+			return nil, err
+		}
+		if b != binaryEscape {
+			dst = append(dst, b)
+			continue
+		}
+		next, err := r.readByte(false)
+		if err != nil {
+			return nil, err
+		}
+		switch next {
+		case binaryTerminatorEnd:
+			return dst, nil
+		case binaryEscapedZero:
+			dst = append(dst, binaryEscape)
+		default:
+			return nil, ErrInvalidSortKey
+		}
+	}
+}
+
+// skipFraction skips packed fractional digits written by appendPackedFraction
+func (r *binaryKeyReader) skipFraction(invert bool) error {
+	for {
+		b, err := r.readByte(invert)
+		if err != nil {
+			return err
+		}
+		if b>>4 == 0 || b&0x0F == 0 {
+			return nil
+		}
+	}
+}
+
+// decodeBinarySortKey is the configuration-based implementation of DecodeNaturalSortKey
+func decodeBinarySortKey(key []byte, config ExternalSortKeyConfig) (string, error) {
+	format := config.numberFormat()
+	reader := &binaryKeyReader{key: key}
+	var result []byte
+
+	for reader.pos < len(key) {
+		marker, _ := reader.readByte(false)
+		switch marker {
+		case binaryEndMarker:
+			// The trailing section holds the exact original input
+			return string(key[reader.pos:]), nil
+		case binaryAlphaMarker:
+			var err error
+			if result, err = reader.readText(result); err != nil {
+				return "", err
+			}
+		case binaryNumericMarker:
+			var err error
+			if result, err = reader.readNumber(result, format); err != nil {
+				return "", err
+			}
+		default:
+			return "", ErrInvalidSortKey
+		}
+	}
+
+	return string(result), nil
+}
+
+// readNumber reads a numeric token written by appendBinaryNumber and appends
+// its original text to dst
+func (r *binaryKeyReader) readNumber(dst []byte, format numberFormat) ([]byte, error) {
+	negative := false
+	if format.signMode != SignNone {
+		sign, err := r.readByte(false)
+		if err != nil || (sign != binaryNegative && sign != binaryPositive) {
+			return nil, ErrInvalidSortKey
+		}
+		negative = sign == binaryNegative
+	}
+
+	significant, err := r.readUint(negative)
+	if err != nil || significant > uint64(len(r.key)*2) {
+		return nil, ErrInvalidSortKey
+	}
+	digitsStart := r.pos
+	r.pos += int(significant+1) / 2
+	if r.pos > len(r.key) {
+		return nil, ErrInvalidSortKey
+	}
+	digits := r.key[digitsStart:r.pos]
+	if format.decimalSeparator != 0 {
+		if err := r.skipFraction(negative); err != nil {
+			return nil, err
+		}
+	}
+
+	length, err := r.readUint(false)
+	if err != nil || length < significant {
+		return nil, ErrInvalidSortKey
+	}
+
+	// Numbers that cannot be rebuilt from their digits carry the original text
+	if r.pos < len(r.key) && r.key[r.pos] == binaryOriginalMarker {
+		r.pos++
+		return r.readText(dst)
+	}
+	if format != (numberFormat{}) {
+		return nil, ErrInvalidSortKey
+	}
+
+	// Default ASCII integers: leading zeros followed by the significant digits
+	for i := significant; i < length; i++ {
+		dst = append(dst, '0')
+	}
+	for i := 0; i < int(significant); i++ {
+		nibble := digits[i/2] >> 4
+		if i%2 == 1 {
+			nibble = digits[i/2] & 0x0F
+		}
+		if nibble > 9 {
+			return nil, ErrInvalidSortKey
+		}
+		dst = append(dst, '0'+nibble)
+	}
+	return dst, nil
+}
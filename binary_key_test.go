@@ -0,0 +1,97 @@
+package ansort
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// TestBinarySortKeyMatchesCompare verifies that binary keys sort like Compare and decode losslessly
+func TestBinarySortKeyMatchesCompare(t *testing.T) {
+	configs := map[string][]ExternalSortKeyOption{
+		"default":          nil,
+		"case insensitive": {WithExternalCaseInsensitive()},
+		"decimal":          {WithExternalDecimalNumbers('.')},
+		"signed decimal case insensitive": {
+			WithExternalSignedNumbers(SignAlways), WithExternalDecimalNumbers(','), WithExternalCaseInsensitive(),
+		},
+	}
+
+	rng := rand.New(rand.NewSource(7))
+	for name, options := range configs {
+		t.Run(name, func(t *testing.T) {
+			compareOptions := []Option{withConfig(buildExternalSortKeyConfig(options...).compareConfig())}
+
+			for i := 0; i < 5000; i++ {
+				a := randomKeyTestString(rng)
+				b := randomKeyTestString(rng)
+				keyA := ToNaturalSortKeyBytes(a, options...)
+				keyB := ToNaturalSortKeyBytes(b, options...)
+
+				expected := CompareLegacy(a, b, compareOptions...)
+				got := bytes.Compare(keyA, keyB)
+				if expected != 0 && got != expected {
+					t.Fatalf("key order %d differs from Compare %d for %q vs %q\nkeys: %x vs %x", got, expected, a, b, keyA, keyB)
+				}
+				if (got == 0) != (a == b) {
+					t.Fatalf("keys for %q and %q should be equal only for equal inputs", a, b)
+				}
+
+				decoded, err := DecodeNaturalSortKey(keyA, options...)
+				if err != nil || decoded != a {
+					t.Fatalf("DecodeNaturalSortKey(%x) = %q, %v; want %q", keyA, decoded, err, a)
+				}
+			}
+		})
+	}
+}
+
+// TestAppendNaturalSortKey tests appending into a caller-supplied buffer
+func TestAppendNaturalSortKey(t *testing.T) {
+	buf := []byte("prefix/")
+	buf = AppendNaturalSortKey(buf, "file10.txt")
+	if !bytes.HasPrefix(buf, []byte("prefix/")) {
+		t.Errorf("AppendNaturalSortKey should keep existing buffer contents, got %q", buf)
+	}
+	if !bytes.Equal(buf[len("prefix/"):], ToNaturalSortKeyBytes("file10.txt")) {
+		t.Errorf("AppendNaturalSortKey and ToNaturalSortKeyBytes produce different keys")
+	}
+
+	if bytes.Compare(ToNaturalSortKeyBytes("item9"), ToNaturalSortKeyBytes("item12345678901234567890")) >= 0 {
+		t.Error("Binary keys should order numbers of any length by magnitude")
+	}
+
+	config := buildExternalSortKeyConfig(WithExternalCaseInsensitive())
+	scratch := make([]byte, 0, 256)
+	for _, input := range []string{"file10.txt", "BlueBungalow_105_test", "v1.2.10.build456"} {
+		allocs := testing.AllocsPerRun(100, func() {
+			scratch = AppendNaturalSortKey(scratch[:0], input)
+			scratch = AppendNaturalSortKeyWithConfig(scratch[:0], input, config)
+		})
+		if allocs != 0 {
+			t.Errorf("AppendNaturalSortKey(%q) allocated %.0f times, want 0", input, allocs)
+		}
+	}
+}
+
+// TestDecodeNaturalSortKeyErrors tests decoding of malformed keys
+func TestDecodeNaturalSortKeyErrors(t *testing.T) {
+	valid := ToNaturalSortKeyBytes("file10.txt")
+	malformed := [][]byte{
+		{0x07},
+		valid[:len(valid)-1],
+		{binaryAlphaMarker, 'a'},
+		{binaryNumericMarker, 0x09},
+	}
+
+	for _, key := range malformed {
+		if _, err := DecodeNaturalSortKey(key); !errors.Is(err, ErrInvalidSortKey) {
+			t.Errorf("DecodeNaturalSortKey(%x) error = %v, want ErrInvalidSortKey", key, err)
+		}
+	}
+
+	if decoded, err := DecodeNaturalSortKey(nil); err != nil || decoded != "" {
+		t.Errorf("DecodeNaturalSortKey(nil) = %q, %v; want empty string", decoded, err)
+	}
+}
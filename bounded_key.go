@@ -0,0 +1,109 @@
+package ansort
+
+import (
+	"unicode/utf8"
+)
+
+// SortKeyResult is an external sort key together with whether it was truncated
+type SortKeyResult struct {
+	// Key is the generated sort key
+	Key string
+	// Truncated reports whether Key was shortened to fit MaxKeyBytes. Inputs
+	// whose truncated keys are equal must be ordered with Compare.
+	Truncated bool
+}
+
+// WithMaxKeyBytes limits external sort keys to at most n bytes, for index
+// engines that cap key size (InnoDB index prefixes, DynamoDB sort keys,
+// Elasticsearch ignore_above). Truncation never reorders two keys: keys that
+// differ within the limit keep their order, and keys that differ only beyond
+// it become ties. Use ToNaturalSortKeyResult to learn which keys were
+// truncated, so tied ranges can be re-sorted in memory with Compare.
+//
+// Truncated string keys of valid UTF-8 inputs remain valid UTF-8: a character
+// that does not fit is replaced by the largest character of the remaining
+// length, which still sorts below it. Binary keys are cut at n bytes.
+// Truncated keys cannot be decoded. A value of 0 disables the limit.
+//
+// Example:
+//
+//	result := ansort.ToNaturalSortKeyResult(longTitle, ansort.WithMaxKeyBytes(1024))
+//	if result.Truncated {
+//		// Rows with this key may need ansort.Compare to break ties
+//	}
+func WithMaxKeyBytes(n int) ExternalSortKeyOption {
+	return func(c *ExternalSortKeyConfig) {
+		c.MaxKeyBytes = n
+	}
+}
+
+// ToNaturalSortKeyResult generates an external sort key like ToNaturalSortKey
+// and reports whether it was truncated to fit MaxKeyBytes.
+//
+// Example:
+//
+//	result := ansort.ToNaturalSortKeyResult("file10", ansort.WithMaxKeyBytes(8))
+//	// result.Key: "file0000", result.Truncated: true
+func ToNaturalSortKeyResult(input string, options ...ExternalSortKeyOption) SortKeyResult {
+	config := buildExternalSortKeyConfig(options...)
+	return generateSortKeyResult(input, config)
+}
+
+// validateMaxKeyBytes validates the key size limit
+// Returns an error if the limit is negative or leaves no room for the header
+func validateMaxKeyBytes(config ExternalSortKeyConfig) error {
+	if config.MaxKeyBytes < 0 {
+		return &ValidationError{
+			Field:   "MaxKeyBytes",
+			Message: "must be 0 (unlimited) or greater",
+		}
+	}
+	if config.Header && config.MaxKeyBytes > 0 && config.MaxKeyBytes <= len(keyHeader(config)) {
+		return &ValidationError{
+			Field:   "MaxKeyBytes",
+			Message: "must be larger than the key header",
+		}
+	}
+	return nil
+}
+
+// truncateSortKey shortens a key to at most maxBytes bytes without reordering.
+//
+// Plain byte truncation preserves order, but may split a UTF-8 character.
+// Dropping the partial character instead could reorder keys: a shorter
+// character that fits would then sort above a longer, larger one that does
+// not. The character that does not fit is therefore replaced by the largest
+// character encoded in the remaining bytes, which sorts at or above every
+// character that fits there and below the one it replaces.
+// Keys that are not valid UTF-8 are truncated byte-wise.
+func truncateSortKey(key string, maxBytes int) (string, bool) {
+	if len(key) <= maxBytes {
+		return key, false
+	}
+	if !utf8.ValidString(key) {
+		return key[:maxBytes], true
+	}
+
+	end := 0
+	for end < maxBytes {
+		_, size := utf8.DecodeRuneInString(key[end:])
+		if end+size > maxBytes {
+			return key[:end] + string(largestRuneOfLength(maxBytes-end)), true
+		}
+		end += size
+	}
+	return key[:end], true
+}
+
+// largestRuneOfLength returns the largest character whose UTF-8 encoding has
+// the given length, from 1 to 3 bytes
+func largestRuneOfLength(length int) rune {
+	switch length {
+	case 1:
+		return 0x7F
+	case 2:
+		return 0x7FF
+	default:
+		return 0xFFFF
+	}
+}
@@ -0,0 +1,117 @@
+package ansort
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestTruncateSortKey tests UTF-8 aware truncation
+func TestTruncateSortKey(t *testing.T) {
+	tests := []struct {
+		key       string
+		maxBytes  int
+		expected  string
+		truncated bool
+	}{
+		{"file0000000010", 20, "file0000000010", false},
+		{"file0000000010", 14, "file0000000010", false},
+		{"file0000000010", 8, "file0000", true},
+		{"abé", 3, "ab\x7f", true},
+		{"ab€x", 4, "ab߿", true},
+		{"ab€x", 5, "ab€", true},
+		{"a😀", 4, "a￿", true},
+		{"ab\xffcd", 3, "ab\xff", true},
+	}
+
+	for _, tt := range tests {
+		got, truncated := truncateSortKey(tt.key, tt.maxBytes)
+		if got != tt.expected || truncated != tt.truncated {
+			t.Errorf("truncateSortKey(%q, %d) = %q, %v; want %q, %v", tt.key, tt.maxBytes, got, truncated, tt.expected, tt.truncated)
+		}
+	}
+}
+
+// TestMaxKeyBytesNeverReorders verifies that truncation only introduces ties
+func TestMaxKeyBytesNeverReorders(t *testing.T) {
+	alphabet := []rune{'a', 'z', '0', '9', '-', 'é', 'ÿ', 'ߟ', '€', '中', '￮', '😀', '\U0010fff0'}
+	randomString := func(rng *rand.Rand) string {
+		var sb strings.Builder
+		for i := rng.Intn(12); i > 0; i-- {
+			sb.WriteRune(alphabet[rng.Intn(len(alphabet))])
+		}
+		return sb.String()
+	}
+
+	rng := rand.New(rand.NewSource(14))
+	for i := 0; i < 20000; i++ {
+		a, b := randomString(rng), randomString(rng)
+		maxBytes := 1 + rng.Intn(20)
+		options := []ExternalSortKeyOption{WithKeyScheme(KeySchemeOrdered)}
+		if i%2 == 0 {
+			options = []ExternalSortKeyOption{WithExternalCaseInsensitive()}
+		}
+
+		fullA, fullB := ToNaturalSortKey(a, options...), ToNaturalSortKey(b, options...)
+		bounded := append(options, WithMaxKeyBytes(maxBytes))
+		resultA, resultB := ToNaturalSortKeyResult(a, bounded...), ToNaturalSortKeyResult(b, bounded...)
+
+		for _, r := range []SortKeyResult{resultA, resultB} {
+			if len(r.Key) > maxBytes || !utf8.ValidString(r.Key) {
+				t.Fatalf("Invalid bounded key %q for limit %d", r.Key, maxBytes)
+			}
+		}
+		if resultA.Truncated != (len(fullA) > maxBytes) {
+			t.Fatalf("Truncated = %v for key %q with limit %d", resultA.Truncated, fullA, maxBytes)
+		}
+		if full := strings.Compare(fullA, fullB); full != 0 {
+			if bounded := strings.Compare(resultA.Key, resultB.Key); bounded == -full {
+				t.Fatalf("Truncation to %d bytes reordered %q and %q", maxBytes, a, b)
+			}
+		}
+		if resultA.Key != ToNaturalSortKey(a, bounded...) {
+			t.Fatalf("ToNaturalSortKeyResult and ToNaturalSortKey differ for %q", a)
+		}
+	}
+}
+
+// TestMaxKeyBytesBinaryAndHeader tests bounded binary keys and headers
+func TestMaxKeyBytesBinaryAndHeader(t *testing.T) {
+	full := ToNaturalSortKeyBytes("file10", WithDescendingKey())
+	bounded := ToNaturalSortKeyBytes("file10", WithDescendingKey(), WithMaxKeyBytes(5))
+	if !bytes.Equal(bounded, full[:5]) {
+		t.Errorf("Bounded binary key = %q, want %q", bounded, full[:5])
+	}
+
+	result := ToNaturalSortKeyResult("file10", WithKeyHeader(), WithMaxKeyBytes(24))
+	if result.Key != "nsk1:p10s-0-a/24;file000" || !result.Truncated {
+		t.Errorf("Bounded header key = %+v", result)
+	}
+	header, _, err := ParseSortKeyHeader(result.Key)
+	if err != nil || header.Config.MaxKeyBytes != 24 {
+		t.Errorf("Parsed header = %+v, %v; want MaxKeyBytes 24", header, err)
+	}
+	if CompatibleSortKeyConfigs(header.Config, buildExternalSortKeyConfig(WithKeyHeader(), WithMaxKeyBytes(32))) {
+		t.Error("Keys with different limits should not be compatible")
+	}
+}
+
+// TestMaxKeyBytesValidation tests validation and consistency reporting of bounded keys
+func TestMaxKeyBytesValidation(t *testing.T) {
+	if _, err := ToNaturalSortKeyValidated("a", WithMaxKeyBytes(-1)); err == nil {
+		t.Error("Expected error for negative MaxKeyBytes")
+	}
+	if _, err := ToNaturalSortKeyValidated("a", WithKeyHeader(), WithMaxKeyBytes(17)); err == nil {
+		t.Error("Expected error for MaxKeyBytes that only fits the header")
+	}
+
+	report, err := VerifySortKeyConsistency([]string{"report10", "report9"}, []ExternalSortKeyOption{WithMaxKeyBytes(6)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(report.Disagreements) != 1 || report.Disagreements[0].Reason != DisagreementTruncation {
+		t.Errorf("Expected a truncation disagreement, got %+v", report.Disagreements)
+	}
+}
@@ -0,0 +1,539 @@
+package ansort
+
+import (
+	"hash/maphash"
+)
+
+// EvictionStrategy selects how a TokenCache makes room for new entries
+type EvictionStrategy int
+
+const (
+	// EvictionLRU evicts the least recently used entry. This is the default.
+	EvictionLRU EvictionStrategy = iota
+	// EvictionTinyLFU uses W-TinyLFU: new entries pass through a small LRU
+	// window, and only enter the main segmented LRU if a frequency sketch
+	// shows they are used more often than the entry they would replace.
+	// It keeps the hit ratio high on skewed workloads and resists scans of
+	// strings that are only seen once.
+	EvictionTinyLFU
+	// EvictionClear empties the whole cache when it is full. It has the lowest
+	// overhead, but the hit ratio collapses after every flush when the working
+	// set is larger than the cache.
+	EvictionClear
+)
+
+// String returns the name of the eviction strategy
+func (s EvictionStrategy) String() string {
+	switch s {
+	case EvictionLRU:
+		return "lru"
+	case EvictionTinyLFU:
+		return "tinylfu"
+	case EvictionClear:
+		return "clear"
+	default:
+		return "unknown"
+	}
+}
+
+// TokenCacheConfig holds configuration options for a TokenCache
+type TokenCacheConfig struct {
+	// Strategy selects how entries are evicted when the cache is full
+	// Default: EvictionLRU
+	Strategy EvictionStrategy
+	// MaxBytes bounds the memory held by cached entries, counting key bytes
+	// and token slices; 0 means only the entry count is bounded
+	// Default: 0
+	MaxBytes int64
+	// Shards is the number of independently locked shards the entries are
+	// spread across, to reduce lock contention between goroutines
+	// Default: 1
+	Shards int
+}
+
+// TokenCacheOption is a functional option for configuring a TokenCache
+type TokenCacheOption func(*TokenCacheConfig)
+
+// WithEvictionStrategy selects how a TokenCache evicts entries when full.
+// Unknown strategies fall back to EvictionLRU.
+//
+// Example:
+//
+//	cache := ansort.NewTokenCache(5000, ansort.WithEvictionStrategy(ansort.EvictionTinyLFU))
+//	sorter := ansort.NewCachedSorterWithCache(data, cache)
+func WithEvictionStrategy(strategy EvictionStrategy) TokenCacheOption {
+	return func(c *TokenCacheConfig) {
+		c.Strategy = strategy
+	}
+}
+
+// DefaultTokenCacheConfig returns a TokenCacheConfig with default settings
+func DefaultTokenCacheConfig() TokenCacheConfig {
+	return TokenCacheConfig{Strategy: EvictionLRU, Shards: 1}
+}
+
+// tokenStore holds cached tokenizations for a TokenCache shard, which
+// serializes access. Stored token slices are never modified. Each entry has a
+// cost in bytes, so the shard can enforce a byte budget.
+type tokenStore interface {
+	get(key string) ([]Token, bool)
+	// contains reports whether key is cached without counting it as a use
+	contains(key string) bool
+	// put adds or replaces an entry and returns the number of entries evicted
+	// to stay within the entry capacity
+	put(key string, tokens []Token, cost int64) int
+	// evict removes entries according to the eviction strategy and returns
+	// how many were removed, or 0 if the store is empty
+	evict() int
+	len() int
+	bytes() int64
+	clear()
+}
+
+// newTokenStore creates the store for an eviction strategy
+func newTokenStore(strategy EvictionStrategy, capacity int) tokenStore {
+	switch strategy {
+	case EvictionClear:
+		s := &clearStore{capacity: capacity}
+		s.clear()
+		return s
+	case EvictionTinyLFU:
+		return newTinyLFUStore(capacity)
+	default:
+		return newLRUStore(capacity)
+	}
+}
+
+// clearStore empties itself when full
+type clearStore struct {
+	entries  map[string]*cacheEntry
+	capacity int
+	used     int64
+}
+
+func (s *clearStore) get(key string) ([]Token, bool) {
+	if e, ok := s.entries[key]; ok {
+		return e.tokens, true
+	}
+	return nil, false
+}
+
+func (s *clearStore) contains(key string) bool {
+	_, ok := s.entries[key]
+	return ok
+}
+
+func (s *clearStore) put(key string, tokens []Token, cost int64) int {
+	if e, ok := s.entries[key]; ok {
+		s.used += cost - e.cost
+		e.tokens, e.cost = tokens, cost
+		return 0
+	}
+	evicted := 0
+	if len(s.entries) >= s.capacity {
+		evicted = s.evict()
+	}
+	s.entries[key] = &cacheEntry{key: key, tokens: tokens, cost: cost}
+	s.used += cost
+	return evicted
+}
+
+func (s *clearStore) evict() int {
+	evicted := len(s.entries)
+	s.clear()
+	return evicted
+}
+
+func (s *clearStore) len() int {
+	return len(s.entries)
+}
+
+func (s *clearStore) bytes() int64 {
+	return s.used
+}
+
+func (s *clearStore) clear() {
+	s.entries = make(map[string]*cacheEntry, s.capacity)
+	s.used = 0
+}
+
+// cacheEntry is a cached tokenization linked into an entryList
+type cacheEntry struct {
+	key        string
+	tokens     []Token
+	cost       int64
+	segment    cacheSegment
+	prev, next *cacheEntry
+}
+
+// cacheSegment identifies the W-TinyLFU list that holds an entry
+type cacheSegment uint8
+
+const (
+	segmentWindow cacheSegment = iota
+	segmentProbation
+	segmentProtected
+)
+
+// entryList is a doubly linked list of entries, most recently used first
+type entryList struct {
+	root cacheEntry
+	size int
+}
+
+// init empties the list
+func (l *entryList) init() {
+	l.root.next = &l.root
+	l.root.prev = &l.root
+	l.size = 0
+}
+
+// pushFront inserts an entry at the front
+func (l *entryList) pushFront(e *cacheEntry) {
+	e.prev = &l.root
+	e.next = l.root.next
+	l.root.next.prev = e
+	l.root.next = e
+	l.size++
+}
+
+// remove unlinks an entry from the list
+func (l *entryList) remove(e *cacheEntry) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.prev, e.next = nil, nil
+	l.size--
+}
+
+// moveToFront marks an entry as most recently used
+func (l *entryList) moveToFront(e *cacheEntry) {
+	l.remove(e)
+	l.pushFront(e)
+}
+
+// back returns the least recently used entry, or nil if the list is empty
+func (l *entryList) back() *cacheEntry {
+	if l.size == 0 {
+		return nil
+	}
+	return l.root.prev
+}
+
+// lruStore evicts the least recently used entry
+type lruStore struct {
+	entries  map[string]*cacheEntry
+	list     entryList
+	capacity int
+	used     int64
+}
+
+// newLRUStore creates an LRU store holding at most capacity entries
+func newLRUStore(capacity int) *lruStore {
+	s := &lruStore{capacity: capacity}
+	s.clear()
+	return s
+}
+
+func (s *lruStore) get(key string) ([]Token, bool) {
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	s.list.moveToFront(e)
+	return e.tokens, true
+}
+
+func (s *lruStore) contains(key string) bool {
+	_, ok := s.entries[key]
+	return ok
+}
+
+func (s *lruStore) put(key string, tokens []Token, cost int64) int {
+	if e, ok := s.entries[key]; ok {
+		s.used += cost - e.cost
+		e.tokens, e.cost = tokens, cost
+		s.list.moveToFront(e)
+		return 0
+	}
+	evicted := 0
+	if s.list.size >= s.capacity {
+		evicted = s.evict()
+	}
+	e := &cacheEntry{key: key, tokens: tokens, cost: cost}
+	s.list.pushFront(e)
+	s.entries[key] = e
+	s.used += cost
+	return evicted
+}
+
+func (s *lruStore) evict() int {
+	victim := s.list.back()
+	if victim == nil {
+		return 0
+	}
+	s.list.remove(victim)
+	delete(s.entries, victim.key)
+	s.used -= victim.cost
+	return 1
+}
+
+func (s *lruStore) len() int {
+	return len(s.entries)
+}
+
+func (s *lruStore) bytes() int64 {
+	return s.used
+}
+
+func (s *lruStore) clear() {
+	s.entries = make(map[string]*cacheEntry, s.capacity)
+	s.list.init()
+	s.used = 0
+}
+
+// W-TinyLFU proportions, following the Caffeine defaults
+const (
+	tinyLFUWindowPercent    = 1
+	tinyLFUProtectedPercent = 80
+	// tinyLFUSampleFactor sets how many accesses, per entry of capacity, the
+	// frequency sketch records before all counts are halved
+	tinyLFUSampleFactor = 10
+)
+
+// tinyLFUStore implements W-TinyLFU: an LRU admission window in front of a
+// segmented LRU (probation and protected), with admission decided by a
+// frequency sketch
+type tinyLFUStore struct {
+	entries   map[string]*cacheEntry
+	window    entryList
+	probation entryList
+	protected entryList
+
+	capacity          int
+	windowCapacity    int
+	protectedCapacity int
+	sketch            *frequencySketch
+	used              int64
+}
+
+// newTinyLFUStore creates a W-TinyLFU store holding at most capacity entries
+func newTinyLFUStore(capacity int) *tinyLFUStore {
+	windowCapacity := capacity * tinyLFUWindowPercent / 100
+	if windowCapacity < 1 {
+		windowCapacity = 1
+	}
+	s := &tinyLFUStore{
+		capacity:          capacity,
+		windowCapacity:    windowCapacity,
+		protectedCapacity: (capacity - windowCapacity) * tinyLFUProtectedPercent / 100,
+		sketch:            newFrequencySketch(capacity),
+	}
+	s.clear()
+	return s
+}
+
+func (s *tinyLFUStore) get(key string) ([]Token, bool) {
+	s.sketch.increment(key)
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	switch e.segment {
+	case segmentWindow:
+		s.window.moveToFront(e)
+	case segmentProbation:
+		// A second hit promotes the entry, demoting the oldest protected one
+		s.probation.remove(e)
+		e.segment = segmentProtected
+		s.protected.pushFront(e)
+		if s.protected.size > s.protectedCapacity {
+			demoted := s.protected.back()
+			s.protected.remove(demoted)
+			demoted.segment = segmentProbation
+			s.probation.pushFront(demoted)
+		}
+	case segmentProtected:
+		s.protected.moveToFront(e)
+	}
+	return e.tokens, true
+}
+
+func (s *tinyLFUStore) contains(key string) bool {
+	_, ok := s.entries[key]
+	return ok
+}
+
+func (s *tinyLFUStore) put(key string, tokens []Token, cost int64) int {
+	if e, ok := s.entries[key]; ok {
+		s.used += cost - e.cost
+		e.tokens, e.cost = tokens, cost
+		return 0
+	}
+	s.sketch.increment(key)
+
+	e := &cacheEntry{key: key, tokens: tokens, cost: cost, segment: segmentWindow}
+	s.window.pushFront(e)
+	s.entries[key] = e
+	s.used += cost
+	if s.window.size <= s.windowCapacity {
+		return 0
+	}
+
+	// The oldest window entry moves to the main space if there is room, and
+	// otherwise competes with the main space's victim
+	if s.probation.size+s.protected.size < s.capacity-s.windowCapacity {
+		candidate := s.window.back()
+		s.window.remove(candidate)
+		candidate.segment = segmentProbation
+		s.probation.pushFront(candidate)
+		return 0
+	}
+	return s.evict()
+}
+
+// evict removes one entry. The oldest window entry is admitted to the main
+// space only if the sketch shows it is used more often than the main space's
+// victim; the less frequently used of the two is removed.
+func (s *tinyLFUStore) evict() int {
+	candidate := s.window.back()
+	victim := s.probation.back()
+	if victim == nil {
+		victim = s.protected.back()
+	}
+
+	switch {
+	case candidate == nil && victim == nil:
+		return 0
+	case candidate == nil:
+		s.remove(victim)
+	case victim == nil:
+		s.remove(candidate)
+	case s.sketch.frequency(candidate.key) > s.sketch.frequency(victim.key):
+		s.remove(victim)
+		s.window.remove(candidate)
+		candidate.segment = segmentProbation
+		s.probation.pushFront(candidate)
+	default:
+		s.remove(candidate)
+	}
+	return 1
+}
+
+// remove deletes an entry from its segment and the index
+func (s *tinyLFUStore) remove(e *cacheEntry) {
+	switch e.segment {
+	case segmentWindow:
+		s.window.remove(e)
+	case segmentProbation:
+		s.probation.remove(e)
+	case segmentProtected:
+		s.protected.remove(e)
+	}
+	delete(s.entries, e.key)
+	s.used -= e.cost
+}
+
+func (s *tinyLFUStore) len() int {
+	return len(s.entries)
+}
+
+func (s *tinyLFUStore) bytes() int64 {
+	return s.used
+}
+
+func (s *tinyLFUStore) clear() {
+	s.entries = make(map[string]*cacheEntry, s.capacity)
+	s.window.init()
+	s.probation.init()
+	s.protected.init()
+	s.sketch.reset()
+	s.used = 0
+}
+
+// frequencySketchDepth is the number of counter rows of a frequencySketch
+const frequencySketchDepth = 4
+
+// frequencySketch is a count-min sketch of saturating counters that
+// estimates how often keys were seen recently. Counts are halved after a
+// sample of accesses so that old popularity fades.
+type frequencySketch struct {
+	seed       maphash.Seed
+	counters   [frequencySketchDepth][]uint8
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+// frequencySketchMax is the largest count a counter holds
+const frequencySketchMax = 15
+
+// newFrequencySketch creates a sketch sized for a cache of the given capacity
+func newFrequencySketch(capacity int) *frequencySketch {
+	width := 16
+	for width < capacity {
+		width <<= 1
+	}
+	s := &frequencySketch{
+		seed:       maphash.MakeSeed(),
+		mask:       uint64(width - 1),
+		sampleSize: tinyLFUSampleFactor * capacity,
+	}
+	for row := range s.counters {
+		s.counters[row] = make([]uint8, width)
+	}
+	return s
+}
+
+// indexes returns the counter index of key in each row
+func (s *frequencySketch) indexes(key string) [frequencySketchDepth]uint64 {
+	h := maphash.String(s.seed, key)
+	h1, h2 := h, h>>32|h<<32
+	var indexes [frequencySketchDepth]uint64
+	for row := range indexes {
+		indexes[row] = (h1 + uint64(row)*h2) & s.mask
+	}
+	return indexes
+}
+
+// increment records an access to key
+func (s *frequencySketch) increment(key string) {
+	for row, i := range s.indexes(key) {
+		if s.counters[row][i] < frequencySketchMax {
+			s.counters[row][i]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.age()
+	}
+}
+
+// frequency returns the estimated recent access count of key
+func (s *frequencySketch) frequency(key string) uint8 {
+	estimate := uint8(frequencySketchMax)
+	for row, i := range s.indexes(key) {
+		if c := s.counters[row][i]; c < estimate {
+			estimate = c
+		}
+	}
+	return estimate
+}
+
+// age halves every counter
+func (s *frequencySketch) age() {
+	for row := range s.counters {
+		for i := range s.counters[row] {
+			s.counters[row][i] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+// reset clears every counter
+func (s *frequencySketch) reset() {
+	for row := range s.counters {
+		clear(s.counters[row])
+	}
+	s.additions = 0
+}
@@ -0,0 +1,237 @@
+package ansort
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// cacheWorkload replays key accesses against a cache the way the sorters use
+// it (Get, then Put on a miss) and returns the hit ratio
+func cacheWorkload(cache *TokenCache, keys []string) float64 {
+	hits := 0
+	for _, key := range keys {
+		if cache.Get(key) != nil {
+			hits++
+			continue
+		}
+		cache.Put(key, parseString(key))
+	}
+	return float64(hits) / float64(len(keys))
+}
+
+// zipfKeys returns n accesses to distinct keys following a Zipf distribution
+func zipfKeys(n, distinct int, seed int64) []string {
+	rng := rand.New(rand.NewSource(seed))
+	zipf := rand.NewZipf(rng, 1.1, 1, uint64(distinct-1))
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("file_%d.txt", zipf.Uint64())
+	}
+	return keys
+}
+
+// uniformKeys returns n accesses to distinct keys chosen uniformly
+func uniformKeys(n, distinct int, seed int64) []string {
+	rng := rand.New(rand.NewSource(seed))
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("file_%d.txt", rng.Intn(distinct))
+	}
+	return keys
+}
+
+// TestTokenCacheLRUEviction tests that the least recently used entry is evicted
+func TestTokenCacheLRUEviction(t *testing.T) {
+	cache := NewTokenCache(3)
+	if cache.Strategy() != EvictionLRU {
+		t.Errorf("Default strategy = %v, want lru", cache.Strategy())
+	}
+	for _, key := range []string{"a1", "b2", "c3"} {
+		cache.Put(key, parseString(key))
+	}
+	cache.Get("a1")
+	cache.Put("b2", parseString("b2")) // Updating refreshes recency
+	cache.Put("d4", parseString("d4"))
+
+	if cache.Get("c3") != nil {
+		t.Error("Least recently used entry c3 should have been evicted")
+	}
+	for _, key := range []string{"a1", "b2", "d4"} {
+		if cache.Get(key) == nil {
+			t.Errorf("Entry %s should still be cached", key)
+		}
+	}
+	if cache.Size() != 3 {
+		t.Errorf("Size() = %d, want 3", cache.Size())
+	}
+}
+
+// TestTokenCacheClearEviction tests the flush-when-full strategy
+func TestTokenCacheClearEviction(t *testing.T) {
+	cache := NewTokenCache(3, WithEvictionStrategy(EvictionClear))
+	for _, key := range []string{"a1", "b2", "c3", "d4"} {
+		cache.Put(key, parseString(key))
+	}
+	if cache.Size() != 1 || cache.Get("d4") == nil {
+		t.Errorf("Size() = %d, want only the newest entry after a flush", cache.Size())
+	}
+}
+
+// TestTokenCacheTinyLFUResistsScans tests that frequently used entries
+// survive a scan of strings seen only once, which flushes an LRU cache
+func TestTokenCacheTinyLFUResistsScans(t *testing.T) {
+	hot := make([]string, 50)
+	for i := range hot {
+		hot[i] = fmt.Sprintf("hot_%d", i)
+	}
+	// Each hot string recurs after 250 accesses, beyond the reach of LRU
+	scan := uniformKeys(4000, 1000000, 1)
+	keys := make([]string, 0, len(scan)*5/4)
+	for i, key := range scan {
+		keys = append(keys, key)
+		if i%4 == 3 {
+			keys = append(keys, hot[(i/4)%len(hot)])
+		}
+	}
+
+	kept := map[EvictionStrategy]int{}
+	for _, strategy := range []EvictionStrategy{EvictionLRU, EvictionTinyLFU} {
+		cache := NewTokenCache(100, WithEvictionStrategy(strategy))
+		cacheWorkload(cache, keys)
+		for _, key := range hot {
+			if cache.Get(key) != nil {
+				kept[strategy]++
+			}
+		}
+		if cache.Size() > 100 {
+			t.Errorf("%v: Size() = %d exceeds capacity", strategy, cache.Size())
+		}
+	}
+	if kept[EvictionTinyLFU] < 45 || kept[EvictionTinyLFU] <= kept[EvictionLRU] {
+		t.Errorf("Hot entries kept: tinylfu %d, lru %d of %d", kept[EvictionTinyLFU], kept[EvictionLRU], len(hot))
+	}
+}
+
+// TestTokenCacheTinyLFUInvariants tests the segment bookkeeping under a random workload
+func TestTokenCacheTinyLFUInvariants(t *testing.T) {
+	for _, capacity := range []int{1, 2, 7, 150} {
+		cache := NewTokenCache(capacity, WithEvictionStrategy(EvictionTinyLFU))
+		cacheWorkload(cache, zipfKeys(5000, 400, int64(capacity)))
+
+		store := cache.shards[0].store.(*tinyLFUStore)
+		listed := store.window.size + store.probation.size + store.protected.size
+		if len(store.entries) != listed || listed > capacity {
+			t.Errorf("Capacity %d: %d entries, %d listed", capacity, len(store.entries), listed)
+		}
+		if store.window.size > store.windowCapacity || store.protected.size > store.protectedCapacity {
+			t.Errorf("Capacity %d: segment sizes %d/%d/%d exceed limits", capacity,
+				store.window.size, store.probation.size, store.protected.size)
+		}
+
+		cache.Clear()
+		if cache.Size() != 0 || cache.Get("file_1.txt") != nil {
+			t.Errorf("Capacity %d: cache not empty after Clear", capacity)
+		}
+	}
+}
+
+// TestTokenCacheHitRatio tests hit ratios on skewed and oversized workloads
+func TestTokenCacheHitRatio(t *testing.T) {
+	workloads := []struct {
+		name     string
+		capacity int
+		keys     []string
+	}{
+		{"zipf", 500, zipfKeys(50000, 5000, 1)},
+		{"working set 10% over capacity", 1000, uniformKeys(50000, 1100, 1)},
+	}
+	for _, w := range workloads {
+		ratios := map[EvictionStrategy]float64{}
+		for _, strategy := range []EvictionStrategy{EvictionLRU, EvictionTinyLFU, EvictionClear} {
+			ratios[strategy] = cacheWorkload(NewTokenCache(w.capacity, WithEvictionStrategy(strategy)), w.keys)
+		}
+		t.Logf("%s: lru %.3f, tinylfu %.3f, clear %.3f", w.name,
+			ratios[EvictionLRU], ratios[EvictionTinyLFU], ratios[EvictionClear])
+
+		if ratios[EvictionLRU] <= ratios[EvictionClear] || ratios[EvictionTinyLFU] <= ratios[EvictionClear] {
+			t.Errorf("%s: bounded eviction should beat clearing the cache", w.name)
+		}
+	}
+
+	// With a working set just above capacity, LRU keeps most of it
+	if ratio := cacheWorkload(NewTokenCache(1000), uniformKeys(50000, 1100, 2)); ratio < 0.8 {
+		t.Errorf("LRU hit ratio %.3f, want at least 0.8", ratio)
+	}
+}
+
+// TestTokenCacheReturnsCopies tests that cached tokens cannot be modified by callers
+func TestTokenCacheReturnsCopies(t *testing.T) {
+	for _, strategy := range []EvictionStrategy{EvictionLRU, EvictionTinyLFU, EvictionClear} {
+		cache := NewTokenCache(10, WithEvictionStrategy(strategy))
+		tokens := parseString("file10")
+		cache.Put("file10", tokens)
+		tokens[0].Value = "changed"
+		got := cache.Get("file10")
+		got[1].Value = "changed"
+		if again := cache.Get("file10"); again[0].Value != "file" || again[1].Value != "10" {
+			t.Errorf("%v: cached tokens were modified: %v", strategy, again)
+		}
+	}
+}
+
+// TestEvictionStrategyString tests strategy names and the fallback for unknown strategies
+func TestEvictionStrategyString(t *testing.T) {
+	names := map[EvictionStrategy]string{
+		EvictionLRU: "lru", EvictionTinyLFU: "tinylfu", EvictionClear: "clear", EvictionStrategy(9): "unknown",
+	}
+	for strategy, name := range names {
+		if got := strategy.String(); got != name {
+			t.Errorf("String() = %q, want %q", got, name)
+		}
+	}
+	if got := NewTokenCache(5, WithEvictionStrategy(EvictionStrategy(9))).Strategy(); got != EvictionLRU {
+		t.Errorf("Unknown strategy fell back to %v, want lru", got)
+	}
+}
+
+// benchmarkCacheWorkload replays a workload and reports the hit ratio
+func benchmarkCacheWorkload(b *testing.B, capacity int, keys []string) {
+	for _, strategy := range []EvictionStrategy{EvictionLRU, EvictionTinyLFU, EvictionClear} {
+		b.Run(strategy.String(), func(b *testing.B) {
+			cache := NewTokenCache(capacity, WithEvictionStrategy(strategy))
+			hits := 0
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				key := keys[i%len(keys)]
+				if cache.Get(key) != nil {
+					hits++
+					continue
+				}
+				cache.Put(key, parseString(key))
+			}
+			b.ReportMetric(float64(hits)/float64(b.N), "hit-ratio")
+		})
+	}
+}
+
+// BenchmarkTokenCacheZipf measures hit ratio and speed on a skewed workload
+func BenchmarkTokenCacheZipf(b *testing.B) {
+	benchmarkCacheWorkload(b, 1000, zipfKeys(100000, 10000, 1))
+}
+
+// BenchmarkTokenCacheOversizedWorkingSet measures a working set 10% larger than the cache
+func BenchmarkTokenCacheOversizedWorkingSet(b *testing.B) {
+	benchmarkCacheWorkload(b, 1000, uniformKeys(100000, 1100, 1))
+}
+
+// BenchmarkTokenCacheScan measures a hot set interleaved with one-off strings
+func BenchmarkTokenCacheScan(b *testing.B) {
+	hot := zipfKeys(50000, 500, 1)
+	scan := uniformKeys(50000, 1000000, 2)
+	keys := make([]string, 0, len(hot)+len(scan))
+	for i := range hot {
+		keys = append(keys, hot[i], scan[i])
+	}
+	benchmarkCacheWorkload(b, 1000, keys)
+}
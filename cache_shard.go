@@ -0,0 +1,195 @@
+package ansort
+
+import (
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// tokenSize is the size of a Token value in a cached token slice
+const tokenSize = int64(unsafe.Sizeof(Token{}))
+
+// WithCacheMaxBytes bounds the memory held by a TokenCache. An entry costs
+// its key bytes plus its token slice; token values share the key's bytes
+// and are not counted again. Entries are evicted according to the eviction
+// strategy until the new entry fits, and entries larger than a shard's
+// budget are not cached. The entry count limit still applies.
+//
+// Example:
+//
+//	// Hold at most 64 MiB of tokenized paths, however long they are
+//	cache := ansort.NewTokenCache(1_000_000, ansort.WithCacheMaxBytes(64<<20))
+func WithCacheMaxBytes(maxBytes int64) TokenCacheOption {
+	return func(c *TokenCacheConfig) {
+		c.MaxBytes = maxBytes
+	}
+}
+
+// WithCacheShards spreads the entries of a TokenCache across n shards, each
+// with its own lock, entry limit and share of the byte budget. Use it when
+// many goroutines compare through the same cache. The limits are split so
+// that the shards together hold no more than the configured totals. The
+// number of shards is capped at the cache size and the byte budget.
+//
+// Example:
+//
+//	ansort.ConfigureCache(10000, ansort.WithCacheShards(16))
+func WithCacheShards(n int) TokenCacheOption {
+	return func(c *TokenCacheConfig) {
+		c.Shards = n
+	}
+}
+
+// buildTokenCacheConfig creates a token cache configuration from options
+func buildTokenCacheConfig(options ...TokenCacheOption) TokenCacheConfig {
+	config := DefaultTokenCacheConfig()
+	for _, option := range options {
+		option(&config)
+	}
+	return config
+}
+
+// validateTokenCacheConfig validates the token cache options
+// Returns an error if the configuration is invalid
+func validateTokenCacheConfig(config TokenCacheConfig) error {
+	if config.Strategy < EvictionLRU || config.Strategy > EvictionClear {
+		return &ValidationError{
+			Field:   "Strategy",
+			Message: "must be EvictionLRU, EvictionTinyLFU or EvictionClear",
+		}
+	}
+	if config.MaxBytes < 0 {
+		return &ValidationError{
+			Field:   "MaxBytes",
+			Message: "must not be negative",
+		}
+	}
+	if config.Shards <= 0 {
+		return &ValidationError{
+			Field:   "Shards",
+			Message: "must be greater than 0",
+		}
+	}
+	return nil
+}
+
+// TokenCacheShardStats reports the usage of one TokenCache shard
+type TokenCacheShardStats struct {
+	// Entries is the number of cached tokenizations
+	Entries int
+	// Bytes is the memory held by the cached entries
+	Bytes int64
+	// MaxEntries is the entry capacity of the shard
+	MaxEntries int
+	// MaxBytes is the byte budget of the shard, or 0 if unbounded
+	MaxBytes int64
+	// Hits is the number of Get calls that found an entry
+	Hits int64
+	// Misses is the number of Get calls that found no entry
+	Misses int64
+	// Evictions is the number of entries removed to make room
+	Evictions int64
+}
+
+// tokenCacheShard is an independently locked part of a TokenCache
+type tokenCacheShard struct {
+	mu       sync.RWMutex
+	store    tokenStore
+	strategy EvictionStrategy
+	maxSize  int
+	maxBytes int64
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+// entryCost returns the number of bytes a cache entry holds
+func entryCost(key string, tokens []Token) int64 {
+	return int64(len(key)) + int64(len(tokens))*tokenSize
+}
+
+// get returns the cached tokens of key. The slice must not be modified.
+func (s *tokenCacheShard) get(key string) ([]Token, bool) {
+	// Recency-based strategies update their lists on every hit
+	if s.strategy == EvictionClear {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+	} else {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	tokens, ok := s.store.get(key)
+	if ok {
+		s.hits.Add(1)
+	} else {
+		s.misses.Add(1)
+	}
+	return tokens, ok
+}
+
+// put caches tokens, which the shard takes ownership of, evicting entries
+// until the shard is within its entry capacity and byte budget
+func (s *tokenCacheShard) put(key string, tokens []Token) {
+	cost := entryCost(key, tokens)
+	if s.maxBytes > 0 && cost > s.maxBytes {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A cached entry is replaced in place, so only growth in its cost
+	// needs room and other entries are not evicted for its old cost
+	evicted := 0
+	if s.store.contains(key) {
+		evicted += s.store.put(key, tokens, cost)
+		evicted += s.makeRoom(0)
+	}
+	if !s.store.contains(key) {
+		evicted += s.makeRoom(cost)
+		evicted += s.store.put(key, tokens, cost)
+	}
+	if evicted > 0 {
+		s.evictions.Add(int64(evicted))
+	}
+}
+
+// makeRoom evicts entries until incoming more bytes fit in the byte budget
+// and returns the number evicted
+func (s *tokenCacheShard) makeRoom(incoming int64) int {
+	evicted := 0
+	for s.maxBytes > 0 && s.store.bytes()+incoming > s.maxBytes {
+		n := s.store.evict()
+		if n == 0 {
+			break
+		}
+		evicted += n
+	}
+	return evicted
+}
+
+// stats returns the shard's usage
+func (s *tokenCacheShard) stats() TokenCacheShardStats {
+	s.mu.RLock()
+	entries, bytes := s.store.len(), s.store.bytes()
+	s.mu.RUnlock()
+
+	return TokenCacheShardStats{
+		Entries:    entries,
+		Bytes:      bytes,
+		MaxEntries: s.maxSize,
+		MaxBytes:   s.maxBytes,
+		Hits:       s.hits.Load(),
+		Misses:     s.misses.Load(),
+		Evictions:  s.evictions.Load(),
+	}
+}
+
+// clear empties the shard
+func (s *tokenCacheShard) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store.clear()
+}
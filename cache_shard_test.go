@@ -0,0 +1,268 @@
+package ansort
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestTokenCacheByteBudget tests that each strategy stays within its byte budget
+func TestTokenCacheByteBudget(t *testing.T) {
+	const budget = 4096
+	for _, strategy := range []EvictionStrategy{EvictionLRU, EvictionTinyLFU, EvictionClear} {
+		cache := NewTokenCache(1000, WithEvictionStrategy(strategy), WithCacheMaxBytes(budget))
+		for i := 0; i < 500; i++ {
+			path := fmt.Sprintf("%s/file%d.txt", strings.Repeat("dir/", i%20), i)
+			cache.Put(path, parseString(path))
+			if bytes := cache.Bytes(); bytes > budget {
+				t.Fatalf("%v: Bytes() = %d exceeds budget after %d puts", strategy, bytes, i+1)
+			}
+		}
+
+		stats := cache.ShardStats()[0]
+		if stats.Evictions == 0 || stats.Entries == 0 || stats.Entries >= 500 {
+			t.Errorf("%v: stats = %+v, want evictions under byte pressure", strategy, stats)
+		}
+		if stats.MaxBytes != budget || stats.MaxEntries != 1000 {
+			t.Errorf("%v: limits = %d bytes, %d entries", strategy, stats.MaxBytes, stats.MaxEntries)
+		}
+	}
+}
+
+// TestTokenCacheByteBudgetReplacement tests that replacing an entry in a
+// full byte-bounded cache keeps the other entries
+func TestTokenCacheByteBudgetReplacement(t *testing.T) {
+	keys := []string{"file1.txt", "file2.txt", "file3.txt", "file4.txt"}
+	budget := int64(len(keys)) * entryCost(keys[0], parseString(keys[0]))
+	for _, strategy := range []EvictionStrategy{EvictionLRU, EvictionTinyLFU, EvictionClear} {
+		cache := NewTokenCache(10, WithEvictionStrategy(strategy), WithCacheMaxBytes(budget))
+		for _, key := range keys {
+			cache.Put(key, parseString(key))
+		}
+		if cache.Bytes() != budget {
+			t.Fatalf("%v: Bytes() = %d, want a full budget of %d", strategy, cache.Bytes(), budget)
+		}
+
+		cache.Put(keys[0], parseString(keys[0]))
+		if stats := cache.ShardStats()[0]; stats.Evictions != 0 || stats.Entries != len(keys) {
+			t.Errorf("%v: stats after replacement = %+v, want no evictions", strategy, stats)
+		}
+		for _, key := range keys {
+			if cache.Get(key) == nil {
+				t.Errorf("%v: entry %s was evicted by the replacement", strategy, key)
+			}
+		}
+	}
+}
+
+// TestTokenCacheEntryCost tests byte accounting for puts, replacements and clears
+func TestTokenCacheEntryCost(t *testing.T) {
+	cache := NewTokenCache(10)
+	a, b := parseString("file10.txt"), parseString("a1")
+	cache.Put("file10.txt", a)
+	cache.Put("a1", b)
+	want := int64(len("file10.txt")+len("a1")) + int64(len(a)+len(b))*tokenSize
+	if got := cache.Bytes(); got != want {
+		t.Errorf("Bytes() = %d, want %d", got, want)
+	}
+
+	cache.Put("a1", parseString("a1")[:1])
+	if got := cache.Bytes(); got != want-tokenSize {
+		t.Errorf("Bytes() after replacement = %d, want %d", got, want-tokenSize)
+	}
+
+	cache.Clear()
+	if cache.Bytes() != 0 {
+		t.Errorf("Bytes() after Clear = %d, want 0", cache.Bytes())
+	}
+}
+
+// TestTokenCacheOversizedEntry tests that entries larger than a shard's budget are not cached
+func TestTokenCacheOversizedEntry(t *testing.T) {
+	cache := NewTokenCache(100, WithCacheMaxBytes(64))
+	cache.Put("small1", parseString("small1"))
+	huge := strings.Repeat("x", 100)
+	cache.Put(huge, parseString(huge))
+
+	if cache.Get(huge) != nil {
+		t.Error("An entry larger than the budget should not be cached")
+	}
+	if cache.Get("small1") == nil {
+		t.Error("An oversized entry should not evict other entries")
+	}
+}
+
+// TestTokenCacheShards tests that entries and stats are spread across shards
+func TestTokenCacheShards(t *testing.T) {
+	cache := NewTokenCache(1000, WithCacheShards(8), WithCacheMaxBytes(80000))
+	for i := 0; i < 400; i++ {
+		key := fmt.Sprintf("item_%d", i)
+		cache.Put(key, parseString(key))
+		cache.Get(key)
+		cache.Get(key + "_missing")
+	}
+
+	stats := cache.ShardStats()
+	if len(stats) != 8 {
+		t.Fatalf("Got %d shards, want 8", len(stats))
+	}
+	var entries int
+	var hits, misses int64
+	for i, shard := range stats {
+		if shard.Entries == 0 {
+			t.Errorf("Shard %d is empty", i)
+		}
+		if shard.MaxEntries != 125 || shard.MaxBytes != 10000 {
+			t.Errorf("Shard %d limits = %d entries, %d bytes", i, shard.MaxEntries, shard.MaxBytes)
+		}
+		entries += shard.Entries
+		hits += shard.Hits
+		misses += shard.Misses
+	}
+	if entries != 400 || entries != cache.Size() {
+		t.Errorf("Shards hold %d entries, Size() = %d, want 400", entries, cache.Size())
+	}
+	if hits != 400 || misses != 400 {
+		t.Errorf("Shards counted %d hits and %d misses, want 400 each", hits, misses)
+	}
+
+	if got := len(NewTokenCache(3, WithCacheShards(8)).ShardStats()); got != 3 {
+		t.Errorf("Shards were not capped at the cache size: got %d", got)
+	}
+	if got := len(NewTokenCache(10, WithCacheShards(8), WithCacheMaxBytes(5)).ShardStats()); got != 5 {
+		t.Errorf("Shards were not capped at the byte budget: got %d", got)
+	}
+
+	// Limits that do not divide evenly still add up to the configured totals
+	limits := []struct {
+		size     int
+		maxBytes int64
+		shards   int
+	}{
+		{1000, 80000, 8},
+		{10, 0, 4},
+		{10, 1001, 4},
+		{7, 20, 7},
+	}
+	for _, l := range limits {
+		var maxEntries int
+		var maxBytes int64
+		shards := NewTokenCache(l.size, WithCacheShards(l.shards), WithCacheMaxBytes(l.maxBytes)).ShardStats()
+		for _, shard := range shards {
+			if shard.MaxEntries < 1 || (l.maxBytes > 0 && shard.MaxBytes < 1) {
+				t.Errorf("%d entries, %d bytes: shard limits = %+v", l.size, l.maxBytes, shard)
+			}
+			maxEntries += shard.MaxEntries
+			maxBytes += shard.MaxBytes
+		}
+		if maxEntries != l.size || maxBytes != l.maxBytes {
+			t.Errorf("%d shards hold up to %d entries and %d bytes, want %d and %d",
+				len(shards), maxEntries, maxBytes, l.size, l.maxBytes)
+		}
+	}
+
+	config := NewTokenCache(10, WithCacheShards(-1), WithCacheMaxBytes(-5)).Config()
+	if config.Shards != 1 || config.MaxBytes != 0 {
+		t.Errorf("Invalid options did not fall back to defaults: %+v", config)
+	}
+}
+
+// TestTokenCacheShardedConcurrentUse tests concurrent access to a sharded,
+// byte-budgeted cache (run with -race)
+func TestTokenCacheShardedConcurrentUse(t *testing.T) {
+	cache := NewTokenCache(200, WithCacheShards(4), WithCacheMaxBytes(16000), WithEvictionStrategy(EvictionTinyLFU))
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				key := fmt.Sprintf("key_%d", (i*(g+1))%500)
+				if tokens := cache.Get(key); tokens != nil && tokens[0].Value != "key_" {
+					t.Errorf("Corrupted entry for %s: %v", key, tokens)
+					return
+				}
+				cache.Put(key, parseString(key))
+			}
+			cache.ShardStats()
+		}(g)
+	}
+	wg.Wait()
+
+	if cache.Size() > 200 || cache.Bytes() > 16000 {
+		t.Errorf("Cache exceeded its limits: %d entries, %d bytes", cache.Size(), cache.Bytes())
+	}
+}
+
+// TestCollatorCacheOptions tests cache configuration through collators and ConfigureCache
+func TestCollatorCacheOptions(t *testing.T) {
+	c, err := NewCollator(
+		WithCollatorCacheSize(64),
+		WithCollatorCacheOptions(WithCacheShards(4), WithCacheMaxBytes(8192), WithEvictionStrategy(EvictionTinyLFU)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	c.Compare("collator_long_string_1", "collator_long_string_2")
+
+	stats := c.Stats()
+	if len(stats.Shards) != 4 || stats.CacheMaxBytes != 8192 || stats.CacheSize != 2 {
+		t.Errorf("Stats = %+v", stats)
+	}
+	if stats.CacheBytes <= 0 || stats.CacheBytes > 8192 {
+		t.Errorf("CacheBytes = %d", stats.CacheBytes)
+	}
+
+	// ConfigureCacheSize keeps the cache options
+	c.ConfigureCacheSize(128)
+	if stats := c.Stats(); len(stats.Shards) != 4 || stats.CacheMaxBytes != 8192 || stats.CacheMaxSize != 128 {
+		t.Errorf("Stats after ConfigureCacheSize = %+v", stats)
+	}
+	c.ConfigureCache(32)
+	if stats := c.Stats(); len(stats.Shards) != 1 || stats.CacheMaxBytes != 0 || stats.CacheMaxSize != 32 {
+		t.Errorf("Stats after ConfigureCache = %+v", stats)
+	}
+
+	ConfigureCache(500, WithCacheShards(2))
+	defer ConfigureCache(2000)
+	if stats := defaultCollator.Stats(); len(stats.Shards) != 2 || stats.CacheMaxSize != 500 {
+		t.Errorf("Default collator stats after ConfigureCache = %+v", stats)
+	}
+}
+
+// TestCollatorCacheOptionsValidation tests error reporting for invalid cache options
+func TestCollatorCacheOptionsValidation(t *testing.T) {
+	invalid := map[string]TokenCacheOption{
+		"Strategy": WithEvictionStrategy(EvictionStrategy(9)),
+		"MaxBytes": WithCacheMaxBytes(-1),
+		"Shards":   WithCacheShards(0),
+	}
+	for field, option := range invalid {
+		var validationErr *ValidationError
+		_, err := NewCollator(WithCollatorCacheOptions(option))
+		if !errors.As(err, &validationErr) || validationErr.Field != field {
+			t.Errorf("%s: error = %v, want ValidationError", field, err)
+		}
+	}
+}
+
+// BenchmarkTokenCacheContention measures parallel lookups with and without sharding
+func BenchmarkTokenCacheContention(b *testing.B) {
+	keys := zipfKeys(10000, 2000, 1)
+	for _, shards := range []int{1, 16} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			cache := NewTokenCache(1000, WithCacheShards(shards))
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := keys[i%len(keys)]
+					if cache.Get(key) == nil {
+						cache.Put(key, parseString(key))
+					}
+					i++
+				}
+			})
+		})
+	}
+}
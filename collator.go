@@ -0,0 +1,425 @@
+package ansort
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// CollatorConfig holds configuration options for a Collator
+type CollatorConfig struct {
+	// Compare holds the settings used by Compare and Sort
+	// Default: DefaultConfig()
+	Compare Config
+	// Key holds the settings used by Key and Keys
+	// Default: DefaultExternalSortKeyConfig()
+	Key ExternalSortKeyConfig
+	// CacheSize is the maximum number of tokenized strings kept in the cache
+	// Default: 2000
+	CacheSize int
+	// CacheConfig holds the eviction strategy, byte budget and sharding of
+	// the collator's cache
+	// Default: DefaultTokenCacheConfig()
+	CacheConfig TokenCacheConfig
+	// Cache is an existing token cache to use instead of creating one, for
+	// example to share a cache between collators. Overrides CacheSize and
+	// CacheConfig.
+	// Default: nil (the collator creates its own cache)
+	Cache *TokenCache
+	// CacheDisabled turns off token caching, as DisableCache does
+	// Default: false
+	CacheDisabled bool
+	// AdaptiveCaching skips the cache when both compared strings are shorter
+	// than 10 bytes, where parsing is cheaper than a cache lookup
+	// Default: true
+	AdaptiveCaching bool
+}
+
+// CollatorOption is a functional option for configuring a Collator
+type CollatorOption func(*CollatorConfig)
+
+// WithCollatorCompareOptions sets the comparison options used by Compare and Sort
+func WithCollatorCompareOptions(options ...Option) CollatorOption {
+	return func(c *CollatorConfig) {
+		c.Compare = buildConfig(options...)
+	}
+}
+
+// WithCollatorKeyOptions sets the sort key options used by Key and Keys
+func WithCollatorKeyOptions(options ...ExternalSortKeyOption) CollatorOption {
+	return func(c *CollatorConfig) {
+		c.Key = buildExternalSortKeyConfig(options...)
+	}
+}
+
+// WithCollatorCacheSize sets the maximum number of cached tokenizations
+func WithCollatorCacheSize(size int) CollatorOption {
+	return func(c *CollatorConfig) {
+		c.CacheSize = size
+	}
+}
+
+// WithCollatorCacheOptions configures the collator's cache, for example its
+// eviction strategy, byte budget or number of shards
+//
+// Example:
+//
+//	c, err := ansort.NewCollator(
+//		ansort.WithCollatorCacheSize(100000),
+//		ansort.WithCollatorCacheOptions(ansort.WithCacheMaxBytes(32<<20), ansort.WithCacheShards(8)))
+func WithCollatorCacheOptions(options ...TokenCacheOption) CollatorOption {
+	return func(c *CollatorConfig) {
+		c.CacheConfig = buildTokenCacheConfig(options...)
+	}
+}
+
+// WithCollatorCache makes the collator use an existing token cache
+func WithCollatorCache(cache *TokenCache) CollatorOption {
+	return func(c *CollatorConfig) {
+		c.Cache = cache
+	}
+}
+
+// WithCollatorCacheDisabled turns off token caching
+func WithCollatorCacheDisabled() CollatorOption {
+	return func(c *CollatorConfig) {
+		c.CacheDisabled = true
+	}
+}
+
+// WithCollatorAdaptiveCaching enables or disables skipping the cache for short strings
+func WithCollatorAdaptiveCaching(enabled bool) CollatorOption {
+	return func(c *CollatorConfig) {
+		c.AdaptiveCaching = enabled
+	}
+}
+
+// DefaultCollatorConfig returns a CollatorConfig with default settings
+func DefaultCollatorConfig() CollatorConfig {
+	return CollatorConfig{
+		Compare:         DefaultConfig(),
+		Key:             DefaultExternalSortKeyConfig(),
+		CacheSize:       2000,
+		CacheConfig:     DefaultTokenCacheConfig(),
+		AdaptiveCaching: true,
+	}
+}
+
+// validateCollatorConfig validates the collator options
+// Returns an error if the configuration is invalid
+func validateCollatorConfig(config CollatorConfig) error {
+	if err := validateConfig(config.Compare); err != nil {
+		return err
+	}
+	if err := validateExternalSortKeyConfig(config.Key); err != nil {
+		return err
+	}
+	if config.Cache != nil {
+		return nil
+	}
+	if config.CacheSize <= 0 {
+		return &ValidationError{
+			Field:   "CacheSize",
+			Message: "must be greater than 0",
+		}
+	}
+	return validateTokenCacheConfig(config.CacheConfig)
+}
+
+// CollatorStats reports the cache usage of a Collator
+type CollatorStats struct {
+	// Hits is the number of tokenizations served from the cache
+	Hits int64
+	// Misses is the number of tokenizations parsed and added to the cache
+	Misses int64
+	// HitRatio is Hits divided by Hits+Misses, or 0 before any lookup
+	HitRatio float64
+	// CacheSize is the number of cached tokenizations
+	CacheSize int
+	// CacheMaxSize is the capacity of the cache
+	CacheMaxSize int
+	// CacheBytes is the memory held by the cached entries
+	CacheBytes int64
+	// CacheMaxBytes is the byte budget of the cache, or 0 if unbounded
+	CacheMaxBytes int64
+	// Shards reports the usage of each cache shard
+	Shards []TokenCacheShardStats
+}
+
+// Collator is a natural sorting engine that owns its configuration, token
+// cache, token pool and statistics. Collators are independent of each other
+// and of the package-level functions, which delegate to a default collator.
+// A Collator is safe for concurrent use.
+type Collator struct {
+	config          Config
+	keyConfig       ExternalSortKeyConfig
+	adaptiveCaching bool
+
+	cache         atomic.Pointer[TokenCache]
+	cacheDisabled atomic.Bool
+	pool          *TokenPool
+
+	metrics collatorMetrics
+	// resetHits and resetMisses are the counts at the last ResetStats
+	resetHits   atomic.Int64
+	resetMisses atomic.Int64
+}
+
+// defaultCollator serves the package-level comparison and cache functions
+var defaultCollator = newCollator(DefaultCollatorConfig())
+
+// NewCollator creates a natural sorting engine with its own cache and options.
+//
+// Returns an error if the comparison or sort key configuration is invalid, or
+// the cache size is not positive.
+//
+// Example:
+//
+//	tenant, err := ansort.NewCollator(
+//		ansort.WithCollatorCompareOptions(ansort.WithCaseInsensitive()),
+//		ansort.WithCollatorKeyOptions(ansort.WithKeyScheme(ansort.KeySchemeOrdered)),
+//		ansort.WithCollatorCacheSize(10000))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	tenant.Sort(names)
+//	key := tenant.Key(names[0])
+func NewCollator(options ...CollatorOption) (*Collator, error) {
+	config := DefaultCollatorConfig()
+	for _, option := range options {
+		option(&config)
+	}
+	if err := validateCollatorConfig(config); err != nil {
+		return nil, err
+	}
+	return newCollator(config), nil
+}
+
+// newCollator creates a collator from a valid configuration
+func newCollator(config CollatorConfig) *Collator {
+	c := &Collator{
+		config:          config.Compare,
+		keyConfig:       config.Key,
+		adaptiveCaching: config.AdaptiveCaching,
+		pool:            NewTokenPool(),
+	}
+	cache := config.Cache
+	if cache == nil {
+		cache = newTokenCacheWithConfig(config.CacheSize, config.CacheConfig)
+	}
+	c.cache.Store(cache)
+	c.cacheDisabled.Store(config.CacheDisabled)
+	return c
+}
+
+// Compare compares two strings using the collator's options, like Compare
+func (c *Collator) Compare(a, b string) int {
+	return c.compareWithConfig(a, b, c.config)
+}
+
+// Sort sorts a slice in place using the collator's options, like SortStrings
+func (c *Collator) Sort(data []string) {
+	c.sortWithConfig(data, c.config)
+}
+
+// Key generates the external sort key of an input using the collator's
+// sort key options, like ToNaturalSortKey
+func (c *Collator) Key(input string) string {
+	return generateSortKeyWithConfig(input, c.keyConfig)
+}
+
+// Keys generates the external sort keys of several inputs, like ToNaturalSortKeys
+func (c *Collator) Keys(inputs []string) []string {
+	if inputs == nil {
+		return nil
+	}
+	keys := make([]string, len(inputs))
+	for i, input := range inputs {
+		keys[i] = generateSortKeyWithConfig(input, c.keyConfig)
+	}
+	return keys
+}
+
+// ConfigureCacheSize replaces the collator's cache with an empty cache of
+// the given size and the same eviction strategy, byte budget and sharding.
+// Comparisons in progress finish with the previous cache.
+func (c *Collator) ConfigureCacheSize(size int) {
+	c.replaceCache(newTokenCacheWithConfig(size, c.cache.Load().Config()))
+}
+
+// ConfigureCache replaces the collator's cache with an empty cache of the
+// given size and options, like NewTokenCache
+func (c *Collator) ConfigureCache(size int, options ...TokenCacheOption) {
+	c.replaceCache(NewTokenCache(size, options...))
+}
+
+// replaceCache swaps in a new cache, keeping the eviction count of the old
+// one in the collator's metrics
+func (c *Collator) replaceCache(cache *TokenCache) {
+	old := c.cache.Swap(cache)
+	c.metrics.retiredEvictions.Add(old.Evictions())
+}
+
+// DisableCache turns off token caching for the collator
+func (c *Collator) DisableCache() {
+	c.cacheDisabled.Store(true)
+}
+
+// EnableCache turns token caching back on after DisableCache
+func (c *Collator) EnableCache() {
+	c.cacheDisabled.Store(false)
+}
+
+// ClearCache empties the collator's cache
+func (c *Collator) ClearCache() {
+	c.cache.Load().Clear()
+}
+
+// Stats returns the collator's cache statistics
+func (c *Collator) Stats() CollatorStats {
+	cache := c.cache.Load()
+	stats := CollatorStats{
+		Hits:          c.metrics.hits.Load() - c.resetHits.Load(),
+		Misses:        c.metrics.misses.Load() - c.resetMisses.Load(),
+		CacheMaxSize:  cache.maxSize,
+		CacheMaxBytes: cache.config.MaxBytes,
+		Shards:        cache.ShardStats(),
+	}
+	for _, shard := range stats.Shards {
+		stats.CacheSize += shard.Entries
+		stats.CacheBytes += shard.Bytes
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// ResetStats resets the hit and miss counters reported by Stats. The
+// counters reported by Metrics are not reset.
+func (c *Collator) ResetStats() {
+	c.resetHits.Store(c.metrics.hits.Load())
+	c.resetMisses.Store(c.metrics.misses.Load())
+}
+
+// compareWithConfig compares two strings with the given configuration,
+// using the cache unless it is disabled or both strings are short
+func (c *Collator) compareWithConfig(a, b string, config Config) int {
+	// Handle identical strings quickly
+	if a == b {
+		return 0
+	}
+
+	if c.cacheDisabled.Load() {
+		return compareParsedStrings(a, b, c.counted(parseString(a)), c.counted(parseString(b)), config)
+	}
+
+	// For short strings, cache overhead may not be worth it
+	if c.adaptiveCaching && len(a) < 10 && len(b) < 10 {
+		return compareParsedStrings(a, b, c.counted(parseStringOptimized(a)), c.counted(parseStringOptimized(b)), config)
+	}
+
+	return c.compareCached(c.cache.Load(), a, b, config)
+}
+
+// compareCached compares two strings using tokens from the given cache
+func (c *Collator) compareCached(cache *TokenCache, a, b string, config Config) int {
+	if a == b {
+		return 0
+	}
+	return compareParsedStrings(a, b, c.tokens(cache, a), c.tokens(cache, b), config)
+}
+
+// tokens returns the tokenization of s from the cache, parsing and caching
+// it on a miss
+func (c *Collator) tokens(cache *TokenCache, s string) []Token {
+	if tokens := cache.Get(s); tokens != nil {
+		c.metrics.hits.Add(1)
+		return tokens
+	}
+	tokens := c.parse(s)
+	cache.Put(s, tokens)
+	c.metrics.misses.Add(1)
+	return tokens
+}
+
+// parse tokenizes s using the collator's token pool
+func (c *Collator) parse(s string) []Token {
+	if len(s) == 0 {
+		return []Token{}
+	}
+
+	// Get a token slice from the pool
+	tokens := c.pool.Get()
+	defer c.pool.Put(tokens)
+
+	if isASCII(s) {
+		tokens = parseStringASCII(s, tokens)
+	} else {
+		tokens = parseStringUnicode(s, tokens)
+	}
+
+	// Return a copy since we're returning the pooled slice
+	result := make([]Token, len(tokens))
+	copy(result, tokens)
+	return c.counted(result)
+}
+
+// counted records a tokenization in the collator's metrics
+func (c *Collator) counted(tokens []Token) []Token {
+	c.metrics.stringsParsed.Add(1)
+	c.metrics.tokensParsed.Add(int64(len(tokens)))
+	return tokens
+}
+
+// sortWithConfig sorts data with the strategy planSort chooses for the
+// given configuration
+func (c *Collator) sortWithConfig(data []string, config Config) {
+	if len(data) <= 1 {
+		return
+	}
+
+	strategy := c.planSort(data, config).Strategy
+	c.metrics.sorts[strategy].Add(1)
+
+	sorter := collatorSorter{data: data, config: config, collator: c}
+	switch strategy {
+	case StrategyCached:
+		sorter.cache = c.cache.Load()
+	case StrategyPooled:
+		sorter.cache = NewTokenCache(len(data))
+	case StrategyPrecomputedKeys:
+		c.sortPrecomputed(data, config, 1)
+		return
+	case StrategyParallel:
+		c.sortPrecomputed(data, config, parallelWorkers(len(data)))
+		return
+	}
+	sort.Sort(sorter)
+}
+
+// collatorSorter implements sort.Interface for a collator. With a cache,
+// every comparison uses it; without, comparisons follow compareWithConfig.
+type collatorSorter struct {
+	data     []string
+	config   Config
+	collator *Collator
+	cache    *TokenCache
+}
+
+// Len implements sort.Interface
+func (s collatorSorter) Len() int {
+	return len(s.data)
+}
+
+// Less implements sort.Interface
+func (s collatorSorter) Less(i, j int) bool {
+	if s.cache != nil {
+		return s.collator.compareCached(s.cache, s.data[i], s.data[j], s.config) < 0
+	}
+	return s.collator.compareWithConfig(s.data[i], s.data[j], s.config) < 0
+}
+
+// Swap implements sort.Interface
+func (s collatorSorter) Swap(i, j int) {
+	s.data[i], s.data[j] = s.data[j], s.data[i]
+}
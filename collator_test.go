@@ -0,0 +1,161 @@
+package ansort
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestCollatorMatchesPackageFunctions tests that collators follow their options
+func TestCollatorMatchesPackageFunctions(t *testing.T) {
+	c, err := NewCollator(
+		WithCollatorCompareOptions(WithCaseInsensitive()),
+		WithCollatorKeyOptions(WithKeyScheme(KeySchemeOrdered), WithLosslessKey()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data := []string{"File10.txt", "file2.txt", "FILE1.txt", "file_with_a_long_name_3", "file_with_a_long_name_20"}
+	expected := append([]string(nil), data...)
+	SortStrings(expected, WithCaseInsensitive())
+	c.Sort(data)
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("Sort() = %v, want %v", data, expected)
+	}
+
+	if got := c.Compare("File1", "file1"); got != 0 {
+		t.Errorf("Compare(File1, file1) = %d, want 0", got)
+	}
+	if got := c.Key("File10"); got != ToNaturalSortKey("File10", WithKeyScheme(KeySchemeOrdered), WithLosslessKey()) {
+		t.Errorf("Key() = %q does not match ToNaturalSortKey", got)
+	}
+	keys := c.Keys(data)
+	for i, input := range data {
+		if keys[i] != c.Key(input) {
+			t.Errorf("Keys()[%d] = %q, want %q", i, keys[i], c.Key(input))
+		}
+	}
+	if c.Keys(nil) != nil {
+		t.Error("Keys(nil) should return nil")
+	}
+}
+
+// TestCollatorIndependence tests that collators do not share caches or stats
+func TestCollatorIndependence(t *testing.T) {
+	a, _ := NewCollator(WithCollatorCacheSize(10))
+	b, _ := NewCollator(WithCollatorCacheSize(500))
+	ResetCacheStats()
+
+	long1, long2 := "a_string_longer_than_ten_1", "a_string_longer_than_ten_2"
+	a.Compare(long1, long2)
+	a.Compare(long1, long2)
+
+	statsA, statsB := a.Stats(), b.Stats()
+	if statsA.Hits != 2 || statsA.Misses != 2 || statsA.CacheSize != 2 || statsA.CacheMaxSize != 10 {
+		t.Errorf("Collator a stats = %+v", statsA)
+	}
+	if statsB.Hits != 0 || statsB.Misses != 0 || statsB.CacheSize != 0 || statsB.CacheMaxSize != 500 {
+		t.Errorf("Collator b stats = %+v", statsB)
+	}
+	if hits, misses, _ := CacheEfficiencyStats(); hits != 0 || misses != 0 {
+		t.Errorf("Package stats = %d hits, %d misses, want none", hits, misses)
+	}
+	if statsA.HitRatio != 0.5 {
+		t.Errorf("HitRatio = %v, want 0.5", statsA.HitRatio)
+	}
+
+	a.ResetStats()
+	a.ClearCache()
+	if stats := a.Stats(); stats.Hits != 0 || stats.Misses != 0 || stats.CacheSize != 0 {
+		t.Errorf("Stats after reset = %+v", stats)
+	}
+}
+
+// TestCollatorCacheControl tests cache configuration of a collator
+func TestCollatorCacheControl(t *testing.T) {
+	shared := NewTokenCache(100)
+	a, _ := NewCollator(WithCollatorCache(shared))
+	b, _ := NewCollator(WithCollatorCache(shared))
+	a.Compare("shared_long_string_1", "shared_long_string_2")
+	b.Compare("shared_long_string_1", "shared_long_string_2")
+	if stats := b.Stats(); stats.Hits != 2 || stats.Misses != 0 {
+		t.Errorf("Shared cache stats = %+v, want 2 hits", stats)
+	}
+
+	c, _ := NewCollator(WithCollatorCacheDisabled())
+	c.Compare("uncached_long_string_1", "uncached_long_string_2")
+	c.EnableCache()
+	c.Compare("cached_long_string_1", "cached_long_string_2")
+	c.DisableCache()
+	c.Compare("uncached_long_string_3", "uncached_long_string_4")
+	if stats := c.Stats(); stats.Misses != 2 || stats.CacheSize != 2 {
+		t.Errorf("Stats = %+v, want only the enabled comparison cached", stats)
+	}
+
+	c.ConfigureCacheSize(42)
+	if stats := c.Stats(); stats.CacheSize != 0 || stats.CacheMaxSize != 42 {
+		t.Errorf("Stats after ConfigureCacheSize = %+v", stats)
+	}
+
+	d, _ := NewCollator(WithCollatorAdaptiveCaching(false))
+	d.Compare("a1", "a2")
+	if stats := d.Stats(); stats.Misses != 2 {
+		t.Errorf("Short strings without adaptive caching: stats = %+v, want 2 misses", stats)
+	}
+}
+
+// TestCollatorValidation tests error reporting for invalid options
+func TestCollatorValidation(t *testing.T) {
+	invalid := [][]CollatorOption{
+		{WithCollatorCompareOptions(WithSignedNumbers(SignMode(9)))},
+		{WithCollatorKeyOptions(WithMaxNumericLength(0))},
+		{WithCollatorCacheSize(0)},
+	}
+	for i, options := range invalid {
+		var validationErr *ValidationError
+		if _, err := NewCollator(options...); !errors.As(err, &validationErr) {
+			t.Errorf("Options %d: error = %v, want ValidationError", i, err)
+		}
+	}
+
+	if _, err := NewCollator(WithCollatorCacheSize(0), WithCollatorCache(NewTokenCache(5))); err != nil {
+		t.Errorf("An explicit cache should not need a cache size: %v", err)
+	}
+}
+
+// TestCollatorConcurrentUse tests concurrent use of collators and the
+// package-level cache functions (run with -race)
+func TestCollatorConcurrentUse(t *testing.T) {
+	c, _ := NewCollator(WithCollatorCacheSize(64))
+	data := make([]string, 400)
+	for i := range data {
+		data[i] = fmt.Sprintf("concurrent_item_%d", (i*7919)%400)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			local := append([]string(nil), data...)
+			c.Sort(local)
+			for i := 1; i < len(local); i++ {
+				if c.Compare(local[i-1], local[i]) > 0 {
+					t.Errorf("Goroutine %d: %q sorted before %q", g, local[i-1], local[i])
+					return
+				}
+			}
+			for i := 0; i < 50; i++ {
+				Compare(data[i], data[i+1])
+				if g == 0 && i%10 == 0 {
+					ConfigureCacheSize(2000)
+					CacheEfficiencyStats()
+				}
+			}
+			c.Stats()
+		}(g)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,92 @@
+package ansort
+
+import (
+	"strings"
+)
+
+// Characters used by KeySchemeCompact numbers. Every character of a number
+// encoding is an ASCII digit or sorts next to the digits, so numbers compare
+// against text exactly like padded numbers do.
+const (
+	// compactLengthContinue extends the length class of numbers with
+	// compactLengthContinue or more significant digits
+	compactLengthContinue = 9
+	// compactFractionEnd ends the fractional digits of a decimal number and
+	// sorts below every digit, so shorter fractions come first
+	compactFractionEnd = '/'
+	// compactComplementBase mirrors '/'..':' so that complemented digits
+	// reverse their order and compactFractionEnd sorts above every digit
+	compactComplementBase = '0' + '9'
+)
+
+// generateCompactSortKey generates a KeySchemeCompact sort key.
+//
+// Text is written as in KeySchemePadded. Numbers are written as a length
+// class followed by their significant digits:
+//   - The length class of n significant digits is the digit '0'+n for n below
+//     9, and '9' followed by the class of n-9 otherwise, so longer numbers
+//     sort after shorter ones at any length.
+//   - In decimal mode, the significant fractional digits follow, ended by
+//     compactFractionEnd.
+//   - Negative numbers are written as '-' (which sorts below every digit)
+//     followed by the complemented encoding of their magnitude.
+func generateCompactSortKey(input string, config ExternalSortKeyConfig) string {
+	format := config.numberFormat()
+	tokens := refineTokens(parseString(input), format)
+
+	var result strings.Builder
+	result.Grow(len(input) + len(tokens))
+
+	for _, token := range tokens {
+		if token.Type == NumericToken {
+			writeCompactNumber(&result, token.Value, format)
+			continue
+		}
+
+		alphaValue := token.Value
+		if !config.CaseSensitive {
+			alphaValue = strings.ToLower(alphaValue)
+		}
+		result.WriteString(alphaValue)
+	}
+
+	return result.String()
+}
+
+// writeCompactNumber writes the KeySchemeCompact encoding of a numeric token
+func writeCompactNumber(result *strings.Builder, value string, format numberFormat) {
+	negative, magnitude := signedMagnitude(value, format)
+	integer, fraction := splitDecimal(magnitude, format.decimalSeparator)
+	integer = strings.TrimLeft(integer, "0")
+
+	var encoded strings.Builder
+	encoded.Grow(len(integer) + len(fraction) + 2)
+	for n := len(integer); ; n -= compactLengthContinue {
+		if n < compactLengthContinue {
+			encoded.WriteByte(byte('0' + n))
+			break
+		}
+		encoded.WriteByte('0' + compactLengthContinue)
+	}
+	encoded.WriteString(integer)
+	if format.decimalSeparator != 0 {
+		encoded.WriteString(strings.TrimRight(fraction, "0"))
+		encoded.WriteByte(compactFractionEnd)
+	}
+
+	if negative {
+		result.WriteByte('-')
+		result.WriteString(complementCompact(encoded.String()))
+	} else {
+		result.WriteString(encoded.String())
+	}
+}
+
+// complementCompact reverses the lexicographic order of a compact number encoding
+func complementCompact(encoded string) string {
+	complemented := []byte(encoded)
+	for i, c := range complemented {
+		complemented[i] = compactComplementBase - c
+	}
+	return string(complemented)
+}
@@ -0,0 +1,91 @@
+package ansort
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// TestCompactKeyScheme tests compact key generation
+func TestCompactKeyScheme(t *testing.T) {
+	tests := []struct {
+		input    string
+		options  []ExternalSortKeyOption
+		expected string
+	}{
+		{"a1b2c3", nil, "a11b12c13"},
+		{"file0", nil, "file0"},
+		{"file007", nil, "file17"},
+		{"v123456789", nil, "v90123456789"},
+		{"v12345678901234567890", nil, "v99212345678901234567890"},
+		{"File10", []ExternalSortKeyOption{WithExternalCaseInsensitive()}, "file210"},
+		{"pi3.140", []ExternalSortKeyOption{WithExternalDecimalNumbers('.')}, "pi1314/"},
+		{"t-12", []ExternalSortKeyOption{WithExternalSignedNumbers(SignAlways)}, "t-787"},
+		{"t-1.5", []ExternalSortKeyOption{WithExternalSignedNumbers(SignAlways), WithExternalDecimalNumbers('.')}, "t-884:"},
+	}
+
+	for _, tt := range tests {
+		options := append([]ExternalSortKeyOption{WithKeyScheme(KeySchemeCompact)}, tt.options...)
+		if got := ToNaturalSortKey(tt.input, options...); got != tt.expected {
+			t.Errorf("ToNaturalSortKey(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+
+	padded := ToNaturalSortKey("a1b2c3")
+	compact := ToNaturalSortKey("a1b2c3", WithKeyScheme(KeySchemeCompact))
+	if len(compact)*3 > len(padded) {
+		t.Errorf("Compact key %q is not much shorter than padded key %q", compact, padded)
+	}
+}
+
+// TestCompactKeySchemeMatchesPadded verifies that compact keys order exactly
+// like padded keys whose padding fits every number
+func TestCompactKeySchemeMatchesPadded(t *testing.T) {
+	configs := [][]ExternalSortKeyOption{
+		nil,
+		{WithExternalCaseInsensitive()},
+		{WithExternalDecimalNumbers('.')},
+		{WithExternalSignedNumbers(SignAlways)},
+		{WithExternalSignedNumbers(SignStandalone), WithExternalDecimalNumbers(',')},
+	}
+
+	rng := rand.New(rand.NewSource(15))
+	for _, options := range configs {
+		config := buildExternalSortKeyConfig(options...)
+		paddedOptions := append([]ExternalSortKeyOption{WithMaxNumericLength(50)}, options...)
+		compactOptions := append([]ExternalSortKeyOption{WithKeyScheme(KeySchemeCompact)}, options...)
+
+		for i := 0; i < 3000; i++ {
+			a, b := randomKeyTestString(rng), randomKeyTestString(rng)
+			if longestNumericLength([]string{a, b}, config.numberFormat()) > 50 {
+				continue
+			}
+			expected := strings.Compare(ToNaturalSortKey(a, paddedOptions...), ToNaturalSortKey(b, paddedOptions...))
+			got := strings.Compare(ToNaturalSortKey(a, compactOptions...), ToNaturalSortKey(b, compactOptions...))
+			if got != expected {
+				t.Fatalf("Compact order %d differs from padded order %d for %q vs %q", got, expected, a, b)
+			}
+		}
+	}
+}
+
+// TestCompactKeySchemeLongNumbers verifies that compact keys order numbers of any length
+func TestCompactKeySchemeLongNumbers(t *testing.T) {
+	var inputs []string
+	for digits := 1; digits <= 40; digits++ {
+		inputs = append(inputs, "n"+strings.Repeat("9", digits), "n1"+strings.Repeat("0", digits))
+	}
+
+	report, err := VerifySortKeyConsistency(inputs, []ExternalSortKeyOption{WithKeyScheme(KeySchemeCompact)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !report.Consistent() {
+		t.Errorf("Compact keys disagree with Compare: %+v", report.Disagreements[0])
+	}
+
+	header, _, err := ParseSortKeyHeader(ToNaturalSortKey("n1", WithKeyScheme(KeySchemeCompact), WithKeyHeader()))
+	if err != nil || header.Config.KeyScheme != KeySchemeCompact {
+		t.Errorf("Parsed header = %+v, %v; want KeySchemeCompact", header, err)
+	}
+}
@@ -0,0 +1,87 @@
+package ansort
+
+import (
+	"strings"
+)
+
+// ToCompositeSortKey encodes several fields into a single external sort key,
+// like ORDER BY field1, field2, ... with natural ordering on each field.
+//
+// Each field is converted with generateSortKeyWithConfig using its own options
+// from fieldOptions (fieldOptions[i] applies to fields[i]; missing entries use
+// the defaults), so case sensitivity, key scheme and direction can differ per
+// field. Field keys are escaped and terminated, so separators never collide
+// with field contents and a field that is a prefix of another sorts first.
+// Extra entries in fieldOptions are ignored; use ToCompositeSortKeyValidated
+// for strict validation.
+//
+// Example:
+//
+//	key := ansort.ToCompositeSortKey(
+//		[]string{"Project2", "build10", "artifact3.zip"},
+//		[]ansort.ExternalSortKeyOption{ansort.WithExternalCaseInsensitive()},
+//		[]ansort.ExternalSortKeyOption{ansort.WithDescendingKey()},
+//	)
+//	// Orders by project (case-insensitive), then newest build first, then artifact
+func ToCompositeSortKey(fields []string, fieldOptions ...[]ExternalSortKeyOption) string {
+	configs := make([]ExternalSortKeyConfig, len(fields))
+	for i := range fields {
+		var options []ExternalSortKeyOption
+		if i < len(fieldOptions) {
+			options = fieldOptions[i]
+		}
+		configs[i] = buildExternalSortKeyConfig(options...)
+	}
+	return generateCompositeSortKey(fields, configs)
+}
+
+// ToCompositeSortKeyValidated encodes several fields into a single external
+// sort key with comprehensive validation. Unlike ToCompositeSortKey, it returns
+// an error if fields is nil, if there are more option sets than fields, or if
+// any field configuration is invalid.
+//
+// Example:
+//
+//	key, err := ansort.ToCompositeSortKeyValidated(
+//		[]string{"Project2", "build10"},
+//		[]ansort.ExternalSortKeyOption{ansort.WithMaxNumericLength(100)},
+//	)
+//	if err != nil {
+//		log.Fatal(err) // Will fail due to excessive padding length
+//	}
+func ToCompositeSortKeyValidated(fields []string, fieldOptions ...[]ExternalSortKeyOption) (string, error) {
+	if err := validateSlice(fields, "ToCompositeSortKeyValidated"); err != nil {
+		return "", err
+	}
+	if len(fieldOptions) > len(fields) {
+		return "", &ValidationError{
+			Field:   "fieldOptions",
+			Message: "cannot have more option sets than fields",
+		}
+	}
+
+	configs := make([]ExternalSortKeyConfig, len(fields))
+	for i := range fields {
+		var options []ExternalSortKeyOption
+		if i < len(fieldOptions) {
+			options = fieldOptions[i]
+		}
+		configs[i] = buildExternalSortKeyConfig(options...)
+		if err := validateExternalSortKeyConfig(configs[i]); err != nil {
+			return "", err
+		}
+	}
+	return generateCompositeSortKey(fields, configs), nil
+}
+
+// generateCompositeSortKey concatenates the escaped and terminated sort key of
+// each field. The escaping preserves the order of each field key and the
+// terminator sorts below any key byte, so comparing composite keys compares
+// field keys one at a time.
+func generateCompositeSortKey(fields []string, configs []ExternalSortKeyConfig) string {
+	var result strings.Builder
+	for i, field := range fields {
+		writeOrderedText(&result, generateSortKeyWithConfig(field, configs[i]))
+	}
+	return result.String()
+}
@@ -0,0 +1,102 @@
+package ansort
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestToCompositeSortKey tests multi-field ordering with per-field options
+func TestToCompositeSortKey(t *testing.T) {
+	rows := [][]string{
+		{"project2", "build10", "a.zip"},
+		{"Project2", "build9", "a.zip"},
+		{"project10", "build1", "a.zip"},
+		{"project2", "build10", "A.zip"},
+		{"project2", "build9", "b.zip"},
+	}
+	fieldOptions := [][]ExternalSortKeyOption{
+		{WithExternalCaseInsensitive()},
+		{WithDescendingKey()},
+	}
+	expected := [][]string{
+		{"project2", "build10", "A.zip"},
+		{"project2", "build10", "a.zip"},
+		{"Project2", "build9", "a.zip"},
+		{"project2", "build9", "b.zip"},
+		{"project10", "build1", "a.zip"},
+	}
+
+	sorted := make([][]string, len(rows))
+	copy(sorted, rows)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return ToCompositeSortKey(sorted[i], fieldOptions...) < ToCompositeSortKey(sorted[j], fieldOptions...)
+	})
+	if !reflect.DeepEqual(sorted, expected) {
+		t.Errorf("Composite key order = %v, want %v", sorted, expected)
+	}
+}
+
+// TestCompositeSortKeyFieldBoundaries verifies that prefix fields never leak into the next field
+func TestCompositeSortKeyFieldBoundaries(t *testing.T) {
+	tests := []struct {
+		smaller, larger []string
+	}{
+		{[]string{"ab", "z"}, []string{"abc", ""}},
+		{[]string{"a", "b"}, []string{"a b", ""}},
+		{[]string{"", "zzz"}, []string{"a", ""}},
+		{[]string{"item9", "x"}, []string{"item10", "a"}},
+		{[]string{"a!", "b"}, []string{"a!\"", "a"}},
+	}
+
+	for _, tt := range tests {
+		smaller := ToCompositeSortKey(tt.smaller)
+		larger := ToCompositeSortKey(tt.larger)
+		if smaller >= larger {
+			t.Errorf("Composite key for %q should sort before %q: %q >= %q", tt.smaller, tt.larger, smaller, larger)
+		}
+	}
+
+	// Random rows must sort field by field
+	rng := rand.New(rand.NewSource(29))
+	fieldOptions := []ExternalSortKeyOption{WithKeyScheme(KeySchemeOrdered)}
+	for i := 0; i < 3000; i++ {
+		a := []string{randomKeyTestString(rng), randomKeyTestString(rng)}
+		b := []string{randomKeyTestString(rng), randomKeyTestString(rng)}
+
+		expected := Compare(a[0], b[0])
+		if expected == 0 {
+			expected = Compare(a[1], b[1])
+		}
+		got := strings.Compare(ToCompositeSortKey(a, fieldOptions, fieldOptions), ToCompositeSortKey(b, fieldOptions, fieldOptions))
+		if got != expected {
+			t.Fatalf("composite order %d differs from field order %d for %q vs %q", got, expected, a, b)
+		}
+	}
+}
+
+// TestToCompositeSortKeyValidated tests validation of composite key options
+func TestToCompositeSortKeyValidated(t *testing.T) {
+	if _, err := ToCompositeSortKeyValidated(nil); err == nil {
+		t.Error("Expected error for nil fields")
+	}
+
+	if _, err := ToCompositeSortKeyValidated([]string{"a"}, nil, nil); err == nil {
+		t.Error("Expected error for more option sets than fields")
+	}
+
+	_, err := ToCompositeSortKeyValidated([]string{"a", "b"}, nil, []ExternalSortKeyOption{WithMaxNumericLength(0)})
+	if valErr, ok := err.(*ValidationError); !ok || valErr.Field != "MaxNumericLength" {
+		t.Errorf("Expected MaxNumericLength ValidationError, got %v", err)
+	}
+
+	key, err := ToCompositeSortKeyValidated([]string{"a1", "b2"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if key != ToCompositeSortKey([]string{"a1", "b2"}) {
+		t.Errorf("Validated and non-validated composite keys differ")
+	}
+}
@@ -1,9 +1,12 @@
 package ansort
 
 import (
+	"bytes"
 	"fmt"
+	"math/rand"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -299,3 +302,84 @@ func TestPerformanceValidation(t *testing.T) {
 		}
 	})
 }
+
+// TestCaseTieBreak tests that the case tie-break gives a total order shared by Compare and sort keys
+func TestCaseTieBreak(t *testing.T) {
+	data := []string{"file1", "File1", "FILE1", "file10", "File2", "file2"}
+	expected := []string{"FILE1", "File1", "file1", "File2", "file2", "file10"}
+
+	sorted := make([]string, len(data))
+	copy(sorted, data)
+	SortStrings(sorted, WithCaseInsensitive(), WithCaseTieBreak())
+	if !reflect.DeepEqual(sorted, expected) {
+		t.Errorf("SortStrings() = %v, want %v", sorted, expected)
+	}
+
+	if Compare("File1", "file1", WithCaseInsensitive()) != 0 {
+		t.Error("Without tie-break, case variants should compare equal")
+	}
+	if Compare("File1", "file1", WithCaseInsensitive(), WithCaseTieBreak()) != -1 {
+		t.Error("With tie-break, File1 should sort before file1")
+	}
+
+	keyOptions := map[string][]ExternalSortKeyOption{
+		"padded":  {WithExternalCaseInsensitive(), WithExternalCaseTieBreak()},
+		"ordered": {WithExternalCaseInsensitive(), WithExternalCaseTieBreak(), WithKeyScheme(KeySchemeOrdered)},
+	}
+	for name, options := range keyOptions {
+		byKey := make([]string, len(data))
+		copy(byKey, data)
+		sort.Slice(byKey, func(i, j int) bool {
+			return ToNaturalSortKey(byKey[i], options...) < ToNaturalSortKey(byKey[j], options...)
+		})
+		if !reflect.DeepEqual(byKey, expected) {
+			t.Errorf("%s keys order = %v, want %v", name, byKey, expected)
+		}
+	}
+
+	byBinaryKey := make([]string, len(data))
+	copy(byBinaryKey, data)
+	sort.Slice(byBinaryKey, func(i, j int) bool {
+		return bytes.Compare(
+			ToNaturalSortKeyBytes(byBinaryKey[i], WithExternalCaseInsensitive(), WithExternalCaseTieBreak()),
+			ToNaturalSortKeyBytes(byBinaryKey[j], WithExternalCaseInsensitive(), WithExternalCaseTieBreak())) < 0
+	})
+	if !reflect.DeepEqual(byBinaryKey, expected) {
+		t.Errorf("binary keys order = %v, want %v", byBinaryKey, expected)
+	}
+
+	// Random inputs: Compare and keys of every requested scheme must agree row for row
+	for _, scheme := range []KeyScheme{KeySchemePadded, KeySchemeOrdered, KeySchemeCompact} {
+		rng := rand.New(rand.NewSource(17))
+		options := []ExternalSortKeyOption{WithExternalCaseInsensitive(), WithExternalCaseTieBreak(), WithKeyScheme(scheme)}
+		for i := 0; i < 3000; i++ {
+			a := randomKeyTestString(rng)
+			b := randomKeyTestString(rng)
+			expected := Compare(a, b, WithCaseInsensitive(), WithCaseTieBreak())
+			got := strings.Compare(ToNaturalSortKey(a, options...), ToNaturalSortKey(b, options...))
+			if got != expected {
+				t.Fatalf("Scheme %d: key order %d differs from Compare %d for %q vs %q", scheme, got, expected, a, b)
+			}
+		}
+	}
+}
+
+// TestCaseTieBreakWithSpaces tests that tie-break keys of inputs containing
+// spaces sort in the same order as Compare
+func TestCaseTieBreakWithSpaces(t *testing.T) {
+	data := []string{"zed a", "Zed", "b a", "B", "b", "zed", "B A", "b.B.", "B0 ", "!1", "9~", "Zed\t1"}
+
+	expected := make([]string, len(data))
+	copy(expected, data)
+	SortStrings(expected, WithCaseInsensitive(), WithCaseTieBreak())
+
+	byKey := make([]string, len(data))
+	copy(byKey, data)
+	options := []ExternalSortKeyOption{WithExternalCaseInsensitive(), WithExternalCaseTieBreak()}
+	sort.Slice(byKey, func(i, j int) bool {
+		return ToNaturalSortKey(byKey[i], options...) < ToNaturalSortKey(byKey[j], options...)
+	})
+	if !reflect.DeepEqual(byKey, expected) {
+		t.Errorf("Key order = %q, want %q", byKey, expected)
+	}
+}
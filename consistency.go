@@ -0,0 +1,446 @@
+package ansort
+
+import (
+	"sort"
+	"strings"
+)
+
+// DisagreementReason identifies why an external sort key orders a pair of
+// inputs differently from Compare
+type DisagreementReason int
+
+const (
+	// DisagreementOther covers disagreements not explained by a known cause,
+	// such as different number formats for keys and Compare
+	DisagreementOther DisagreementReason = iota
+	// DisagreementLeadingZeros means two numbers with equal value but different
+	// leading zeros ("7" and "007") received equal padded keys, losing the
+	// tie-break that Compare applies
+	DisagreementLeadingZeros
+	// DisagreementNumericOverflow means a number has more digits than
+	// MaxNumericLength, so its padded key no longer sorts by magnitude
+	DisagreementNumericOverflow
+	// DisagreementPunctuation means text containing characters that sort below
+	// '0' (such as spaces, '-' or '.') was compared against padded digits
+	DisagreementPunctuation
+	// DisagreementCaseFolding means keys and Compare use different case settings
+	DisagreementCaseFolding
+	// DisagreementTruncation means the keys became equal when truncated to
+	// MaxKeyBytes, so the tie must be broken with Compare
+	DisagreementTruncation
+)
+
+// String returns the name of the disagreement reason
+func (r DisagreementReason) String() string {
+	switch r {
+	case DisagreementLeadingZeros:
+		return "leading-zeros"
+	case DisagreementNumericOverflow:
+		return "numeric-overflow"
+	case DisagreementPunctuation:
+		return "punctuation"
+	case DisagreementCaseFolding:
+		return "case-folding"
+	case DisagreementTruncation:
+		return "truncation"
+	default:
+		return "other"
+	}
+}
+
+// SortKeyDisagreement describes a pair of inputs whose external sort keys are
+// ordered differently from Compare
+type SortKeyDisagreement struct {
+	// A and B are the inputs, with A ordered first (or equal) by Compare
+	A, B string
+	// KeyA and KeyB are the generated sort keys
+	KeyA, KeyB string
+	// Compare is the result of Compare(A, B), either -1 or 0
+	Compare int
+	// KeyCompare is the result of comparing the keys in the direction they are
+	// meant to be scanned (reversed for descending keys)
+	KeyCompare int
+	// TokenA and TokenB are the first tokens at which the inputs differ.
+	// A missing token is reported as an empty AlphaToken.
+	TokenA, TokenB Token
+	// Reason identifies the likely cause of the disagreement
+	Reason DisagreementReason
+}
+
+// ConsistencyReport is the result of VerifySortKeyConsistency
+type ConsistencyReport struct {
+	// Inputs is the number of distinct inputs that were checked
+	Inputs int
+	// Disagreements lists every pair of distinct inputs whose keys disagree with Compare
+	Disagreements []SortKeyDisagreement
+}
+
+// Consistent reports whether sorting by the keys gives the same order as Compare
+func (r *ConsistencyReport) Consistent() bool {
+	return len(r.Disagreements) == 0
+}
+
+// ReasonCounts returns the number of disagreements for each reason
+func (r *ConsistencyReport) ReasonCounts() map[DisagreementReason]int {
+	counts := make(map[DisagreementReason]int)
+	for _, d := range r.Disagreements {
+		counts[d.Reason]++
+	}
+	return counts
+}
+
+// SortKeySuggestion is the result of SuggestSortKeyOptions
+type SortKeySuggestion struct {
+	// MaxNumericLength is the padding length that fits every number in the inputs
+	MaxNumericLength int
+	// CaseSensitive matches the case sensitivity of Compare
+	CaseSensitive bool
+	// KeyScheme is KeySchemeOrdered when padded keys cannot match Compare for
+	// the inputs at any padding length, and KeySchemePadded otherwise
+	KeyScheme KeyScheme
+	// Options generate keys with the suggested settings, keeping every other
+	// setting of the original key options
+	Options []ExternalSortKeyOption
+	// Report verifies the suggested options against the inputs
+	Report *ConsistencyReport
+}
+
+// consistencyEntry holds a distinct input with its tokenizations and key
+type consistencyEntry struct {
+	input     string
+	tokens    []Token
+	refined   []Token
+	key       string
+	truncated bool
+	rank      int
+}
+
+// VerifySortKeyConsistency checks that sorting inputs by their external sort
+// keys gives the same order as sorting them with Compare, and reports every
+// pair of distinct inputs that disagrees, with the offending tokens and a
+// reason code. Keys are generated with keyOptions and compared byte-wise, as
+// databases and search engines do; Compare uses compareOptions, which should
+// match how the application sorts the same data (default: SortStrings
+// defaults). Descending keys are expected to give the reverse order.
+//
+// Run this before backfilling keys into an external system to find inputs that
+// the chosen key options cannot order correctly. The cost grows with the
+// number of disagreeing pairs, which can be quadratic for badly chosen options.
+//
+// Example:
+//
+//	report, err := ansort.VerifySortKeyConsistency(names, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, d := range report.Disagreements {
+//		fmt.Printf("%q / %q: %s\n", d.A, d.B, d.Reason)
+//	}
+func VerifySortKeyConsistency(inputs []string, keyOptions []ExternalSortKeyOption, compareOptions ...Option) (*ConsistencyReport, error) {
+	if err := validateSlice(inputs, "VerifySortKeyConsistency"); err != nil {
+		return nil, err
+	}
+
+	keyConfig := buildExternalSortKeyConfig(keyOptions...)
+	if err := validateExternalSortKeyConfig(keyConfig); err != nil {
+		return nil, err
+	}
+	compareConfig := buildConfig(compareOptions...)
+	if err := validateConfig(compareConfig); err != nil {
+		return nil, err
+	}
+
+	return verifySortKeyConsistency(inputs, keyConfig, compareConfig), nil
+}
+
+// SuggestSortKeyOptions suggests external sort key options that order inputs
+// the same way as Compare with compareOptions. The suggestion keeps keyOptions
+// but takes its case and number settings from compareOptions and raises
+// MaxNumericLength to fit the longest number in the inputs. When padded keys
+// still disagree (for example because of leading zeros or punctuation), it
+// switches to KeySchemeOrdered, which always matches Compare.
+//
+// Example:
+//
+//	suggestion, err := ansort.SuggestSortKeyOptions(names, nil, ansort.WithCaseInsensitive())
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	keys := ansort.ToNaturalSortKeys(names, suggestion.Options...)
+func SuggestSortKeyOptions(inputs []string, keyOptions []ExternalSortKeyOption, compareOptions ...Option) (*SortKeySuggestion, error) {
+	if err := validateSlice(inputs, "SuggestSortKeyOptions"); err != nil {
+		return nil, err
+	}
+
+	keyConfig := buildExternalSortKeyConfig(keyOptions...)
+	if err := validateExternalSortKeyConfig(keyConfig); err != nil {
+		return nil, err
+	}
+	compareConfig := buildConfig(compareOptions...)
+	if err := validateConfig(compareConfig); err != nil {
+		return nil, err
+	}
+
+	suggested := keyConfig
+	suggested.CaseSensitive = compareConfig.CaseSensitive
+	suggested.CaseTieBreak = compareConfig.CaseTieBreak
+	suggested.DecimalNumbers = compareConfig.DecimalNumbers
+	suggested.DecimalSeparator = compareConfig.DecimalSeparator
+	suggested.SignMode = compareConfig.SignMode
+
+	// Fit the longest integer or fractional digit run, within the valid range
+	longest := longestNumericLength(inputs, compareConfig.numberFormat())
+	if longest > suggested.MaxNumericLength {
+		suggested.MaxNumericLength = longest
+	}
+	if suggested.MaxNumericLength > 50 {
+		suggested.MaxNumericLength = 50
+	}
+
+	report := verifySortKeyConsistency(inputs, suggested, compareConfig)
+	if !report.Consistent() {
+		suggested.KeyScheme = KeySchemeOrdered
+		report = verifySortKeyConsistency(inputs, suggested, compareConfig)
+	}
+
+	return &SortKeySuggestion{
+		MaxNumericLength: suggested.MaxNumericLength,
+		CaseSensitive:    suggested.CaseSensitive,
+		KeyScheme:        suggested.KeyScheme,
+		Options:          externalOptionsFor(suggested),
+		Report:           report,
+	}, nil
+}
+
+// verifySortKeyConsistency finds every disagreeing pair for valid configurations.
+//
+// Distinct inputs are sorted by Compare and ranked, with equal inputs sharing a
+// rank. Pairs within a rank disagree when their keys differ. Across ranks, the
+// inputs are ordered by (rank, key) and insertion-sorted into (key, descending
+// rank) order: every swap exchanges exactly one pair whose keys do not follow
+// Compare, so the cost is proportional to the number of disagreements.
+func verifySortKeyConsistency(inputs []string, keyConfig ExternalSortKeyConfig, compareConfig Config) *ConsistencyReport {
+	format := compareConfig.numberFormat()
+	seen := make(map[string]bool, len(inputs))
+	entries := make([]*consistencyEntry, 0, len(inputs))
+	for _, input := range inputs {
+		if seen[input] {
+			continue
+		}
+		seen[input] = true
+
+		tokens := parseString(input)
+		result := generateSortKeyResult(input, keyConfig)
+		entries = append(entries, &consistencyEntry{
+			input:     input,
+			tokens:    tokens,
+			refined:   refineTokens(tokens, format),
+			key:       result.Key,
+			truncated: result.Truncated,
+		})
+	}
+
+	report := &ConsistencyReport{Inputs: len(entries)}
+	keyCompare := func(a, b *consistencyEntry) int {
+		if keyConfig.Descending {
+			return strings.Compare(b.key, a.key)
+		}
+		return strings.Compare(a.key, b.key)
+	}
+	record := func(a, b *consistencyEntry, compare int) {
+		tokenA, tokenB, reason := classifyDisagreement(a.refined, b.refined, keyConfig, compareConfig)
+		if (a.truncated || b.truncated) && a.key == b.key {
+			reason = DisagreementTruncation
+		}
+		report.Disagreements = append(report.Disagreements, SortKeyDisagreement{
+			A:          a.input,
+			B:          b.input,
+			KeyA:       a.key,
+			KeyB:       b.key,
+			Compare:    compare,
+			KeyCompare: keyCompare(a, b),
+			TokenA:     tokenA,
+			TokenB:     tokenB,
+			Reason:     reason,
+		})
+	}
+
+	// Rank the inputs in Compare order
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		return compareParsedStrings(a.input, b.input, a.tokens, b.tokens, compareConfig) < 0
+	})
+	for i := 1; i < len(entries); i++ {
+		a, b := entries[i-1], entries[i]
+		entries[i].rank = a.rank
+		if compareParsedStrings(a.input, b.input, a.tokens, b.tokens, compareConfig) != 0 {
+			entries[i].rank++
+		}
+	}
+
+	// Inputs that Compare treats as equal must have equal keys
+	for start := 0; start < len(entries); {
+		end := start + 1
+		for end < len(entries) && entries[end].rank == entries[start].rank {
+			end++
+		}
+		for i := start; i < end; i++ {
+			for j := i + 1; j < end; j++ {
+				if entries[i].key != entries[j].key {
+					record(entries[i], entries[j], 0)
+				}
+			}
+		}
+		start = end
+	}
+
+	// Inputs with different ranks must have keys in the same order
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].rank != entries[j].rank {
+			return entries[i].rank < entries[j].rank
+		}
+		return keyCompare(entries[i], entries[j]) < 0
+	})
+	for i := 1; i < len(entries); i++ {
+		for j := i; j > 0; j-- {
+			a, b := entries[j-1], entries[j]
+			result := keyCompare(b, a)
+			if result > 0 || (result == 0 && b.rank <= a.rank) {
+				break
+			}
+			record(a, b, -1)
+			entries[j-1], entries[j] = b, a
+		}
+	}
+
+	return report
+}
+
+// classifyDisagreement finds the first differing tokens of two refined
+// tokenizations and the likely reason their keys disagree with Compare
+func classifyDisagreement(tokensA, tokensB []Token, keyConfig ExternalSortKeyConfig, compareConfig Config) (Token, Token, DisagreementReason) {
+	i := 0
+	for i < len(tokensA) && i < len(tokensB) && tokensA[i] == tokensB[i] {
+		i++
+	}
+	var tokenA, tokenB Token
+	if i < len(tokensA) {
+		tokenA = tokensA[i]
+	}
+	if i < len(tokensB) {
+		tokenB = tokensB[i]
+	}
+
+	if keyConfig.numberFormat() != compareConfig.numberFormat() {
+		return tokenA, tokenB, DisagreementOther
+	}
+
+	format := compareConfig.numberFormat()
+	switch {
+	case tokenA.Type == NumericToken && tokenB.Type == NumericToken:
+		if keyConfig.keyScheme() == KeySchemePadded {
+			if numericKeyLength(tokenA.Value, format) > keyConfig.MaxNumericLength ||
+				numericKeyLength(tokenB.Value, format) > keyConfig.MaxNumericLength {
+				return tokenA, tokenB, DisagreementNumericOverflow
+			}
+		}
+		if numericValuesEqual(tokenA.Value, tokenB.Value, format) {
+			return tokenA, tokenB, DisagreementLeadingZeros
+		}
+	case tokenA.Type == AlphaToken && tokenB.Type == AlphaToken &&
+		keyConfig.CaseSensitive != compareConfig.CaseSensitive &&
+		strings.EqualFold(tokenA.Value, tokenB.Value):
+		return tokenA, tokenB, DisagreementCaseFolding
+	}
+
+	if containsBelowDigits(tokenA) || containsBelowDigits(tokenB) {
+		return tokenA, tokenB, DisagreementPunctuation
+	}
+	if keyConfig.CaseSensitive != compareConfig.CaseSensitive {
+		return tokenA, tokenB, DisagreementCaseFolding
+	}
+	return tokenA, tokenB, DisagreementOther
+}
+
+// numericValuesEqual reports whether two numeric tokens have the same value,
+// ignoring the tie-break on their original text
+func numericValuesEqual(a, b string, format numberFormat) bool {
+	aNegative, aMagnitude := signedMagnitude(a, format)
+	bNegative, bMagnitude := signedMagnitude(b, format)
+	return aNegative == bNegative &&
+		compareMagnitudes(aMagnitude, bMagnitude, format.decimalSeparator) == 0
+}
+
+// containsBelowDigits reports whether an alphabetic token contains a byte that
+// sorts below '0', which padded keys cannot order against digits
+func containsBelowDigits(token Token) bool {
+	if token.Type != AlphaToken {
+		return false
+	}
+	for i := 0; i < len(token.Value); i++ {
+		if token.Value[i] < '0' {
+			return true
+		}
+	}
+	return false
+}
+
+// numericKeyLength returns the longest digit run of a numeric token as padded
+// keys see it: the integer digits including leading zeros, or the significant
+// fractional digits
+func numericKeyLength(value string, format numberFormat) int {
+	_, magnitude := signedMagnitude(value, format)
+	integer, fraction := splitDecimal(magnitude, format.decimalSeparator)
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > len(integer) {
+		return len(fraction)
+	}
+	return len(integer)
+}
+
+// longestNumericLength returns the longest numeric digit run in the inputs
+func longestNumericLength(inputs []string, format numberFormat) int {
+	longest := 0
+	for _, input := range inputs {
+		for _, token := range refineTokens(parseString(input), format) {
+			if token.Type != NumericToken {
+				continue
+			}
+			if n := numericKeyLength(token.Value, format); n > longest {
+				longest = n
+			}
+		}
+	}
+	return longest
+}
+
+// externalOptionsFor returns functional options that reproduce a configuration
+func externalOptionsFor(config ExternalSortKeyConfig) []ExternalSortKeyOption {
+	options := []ExternalSortKeyOption{
+		WithMaxNumericLength(config.MaxNumericLength),
+		WithExternalCaseSensitive(config.CaseSensitive),
+		WithKeyScheme(config.KeyScheme),
+	}
+	if config.DecimalNumbers {
+		options = append(options, WithExternalDecimalNumbers(config.DecimalSeparator))
+	}
+	if config.SignMode != SignNone {
+		options = append(options, WithExternalSignedNumbers(config.SignMode))
+	}
+	if config.CaseTieBreak {
+		options = append(options, WithExternalCaseTieBreak())
+	}
+	if config.Lossless {
+		options = append(options, WithLosslessKey())
+	}
+	if config.Descending {
+		options = append(options, WithDescendingKey())
+	}
+	if config.Header {
+		options = append(options, WithKeyHeader())
+	}
+	if config.MaxKeyBytes > 0 {
+		options = append(options, WithMaxKeyBytes(config.MaxKeyBytes))
+	}
+	return options
+}
@@ -0,0 +1,175 @@
+package ansort
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestVerifySortKeyConsistencyReasons tests detection and classification of disagreements
+func TestVerifySortKeyConsistencyReasons(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputs         []string
+		keyOptions     []ExternalSortKeyOption
+		compareOptions []Option
+		expected       DisagreementReason
+	}{
+		{
+			name:     "leading zeros",
+			inputs:   []string{"file007", "file7"},
+			expected: DisagreementLeadingZeros,
+		},
+		{
+			name:       "numeric overflow",
+			inputs:     []string{"id100", "id99"},
+			keyOptions: []ExternalSortKeyOption{WithMaxNumericLength(2)},
+			expected:   DisagreementNumericOverflow,
+		},
+		{
+			name:     "punctuation below digits",
+			inputs:   []string{"a1", "a-b"},
+			expected: DisagreementPunctuation,
+		},
+		{
+			name:       "case folding",
+			inputs:     []string{"a1", "B1"},
+			keyOptions: []ExternalSortKeyOption{WithExternalCaseInsensitive()},
+			expected:   DisagreementCaseFolding,
+		},
+		{
+			name:           "case folding of equal inputs",
+			inputs:         []string{"file1", "FILE1"},
+			compareOptions: []Option{WithCaseInsensitive()},
+			expected:       DisagreementCaseFolding,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := VerifySortKeyConsistency(tt.inputs, tt.keyOptions, tt.compareOptions...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(report.Disagreements) != 1 {
+				t.Fatalf("Expected 1 disagreement, got %+v", report.Disagreements)
+			}
+			d := report.Disagreements[0]
+			if d.Reason != tt.expected {
+				t.Errorf("Reason = %s, want %s", d.Reason, tt.expected)
+			}
+			if d.Compare == d.KeyCompare {
+				t.Errorf("Disagreement with matching results: %+v", d)
+			}
+			if d.TokenA == d.TokenB {
+				t.Errorf("Offending tokens should differ: %+v", d)
+			}
+		})
+	}
+}
+
+// TestVerifySortKeyConsistencyReportsEveryPair compares the report with a brute-force check
+func TestVerifySortKeyConsistencyReportsEveryPair(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	inputs := make([]string, 300)
+	for i := range inputs {
+		inputs[i] = randomKeyTestString(rng)
+	}
+
+	configs := [][]ExternalSortKeyOption{
+		nil,
+		{WithMaxNumericLength(2)},
+		{WithExternalCaseInsensitive(), WithDescendingKey()},
+		{WithKeyScheme(KeySchemeOrdered)},
+		{WithKeyScheme(KeySchemeOrdered), WithExternalCaseInsensitive()},
+	}
+	for _, keyOptions := range configs {
+		report, err := VerifySortKeyConsistency(inputs, keyOptions, WithCaseInsensitive())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		keyConfig := buildExternalSortKeyConfig(keyOptions...)
+		expected := make(map[[2]string]bool)
+		seen := make(map[string]bool)
+		var unique []string
+		for _, input := range inputs {
+			if !seen[input] {
+				seen[input] = true
+				unique = append(unique, input)
+			}
+		}
+		for i, a := range unique {
+			for _, b := range unique[i+1:] {
+				cmp := Compare(a, b, WithCaseInsensitive())
+				keyCmp := strings.Compare(ToNaturalSortKey(a, keyOptions...), ToNaturalSortKey(b, keyOptions...))
+				if keyConfig.Descending {
+					keyCmp = -keyCmp
+				}
+				if cmp != keyCmp {
+					expected[[2]string{a, b}] = true
+				}
+			}
+		}
+
+		if len(report.Disagreements) != len(expected) {
+			t.Errorf("Reported %d disagreements, brute force found %d", len(report.Disagreements), len(expected))
+		}
+		for _, d := range report.Disagreements {
+			if !expected[[2]string{d.A, d.B}] && !expected[[2]string{d.B, d.A}] {
+				t.Errorf("Unexpected disagreement %q / %q", d.A, d.B)
+			}
+		}
+		if keyConfig.KeyScheme == KeySchemeOrdered && !keyConfig.CaseSensitive && !report.Consistent() {
+			t.Errorf("Ordered keys should be consistent, got %d disagreements", len(report.Disagreements))
+		}
+	}
+}
+
+// TestSuggestSortKeyOptions tests that suggestions fix disagreements
+func TestSuggestSortKeyOptions(t *testing.T) {
+	inputs := []string{"File2", "file10", "file123456789012", "File1"}
+	suggestion, err := SuggestSortKeyOptions(inputs, nil, WithCaseInsensitive())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if suggestion.MaxNumericLength != 12 || suggestion.CaseSensitive || suggestion.KeyScheme != KeySchemePadded {
+		t.Errorf("Unexpected suggestion %+v", suggestion)
+	}
+	if !suggestion.Report.Consistent() {
+		t.Errorf("Suggested options should be consistent: %+v", suggestion.Report.Disagreements)
+	}
+
+	sorted := append([]string(nil), inputs...)
+	SortStrings(sorted, WithCaseInsensitive())
+	byKey := append([]string(nil), inputs...)
+	sort.Slice(byKey, func(i, j int) bool {
+		return ToNaturalSortKey(byKey[i], suggestion.Options...) < ToNaturalSortKey(byKey[j], suggestion.Options...)
+	})
+	if strings.Join(sorted, ",") != strings.Join(byKey, ",") {
+		t.Errorf("Key order %v, want %v", byKey, sorted)
+	}
+
+	// Leading zeros cannot be fixed by padding
+	suggestion, err = SuggestSortKeyOptions([]string{"v007", "v7"}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if suggestion.KeyScheme != KeySchemeOrdered || !suggestion.Report.Consistent() {
+		t.Errorf("Expected a consistent ordered scheme, got %+v", suggestion)
+	}
+}
+
+// TestVerifySortKeyConsistencyValidation tests input and configuration validation
+func TestVerifySortKeyConsistencyValidation(t *testing.T) {
+	if _, err := VerifySortKeyConsistency(nil, nil); err == nil {
+		t.Error("Expected error for nil inputs")
+	}
+	if _, err := VerifySortKeyConsistency([]string{"a"}, []ExternalSortKeyOption{WithMaxNumericLength(0)}); err == nil {
+		t.Error("Expected error for invalid key options")
+	}
+	if _, err := SuggestSortKeyOptions([]string{"a"}, nil, WithSignedNumbers(SignMode(9))); err == nil {
+		t.Error("Expected error for invalid compare options")
+	}
+}
@@ -0,0 +1,184 @@
+package ansort
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strconv"
+)
+
+// ErrInvalidCursor is returned when a cursor is malformed or has been modified
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrCursorMismatch is returned when a cursor was created with a different
+// sort key configuration
+var ErrCursorMismatch = errors.New("cursor was created with a different sort key configuration")
+
+// Cursor layout (before base64url encoding):
+//
+//	version (1 byte) | fingerprint (8 bytes) | uvarint key length | key | ID | MAC
+//
+// The fingerprint identifies the key configuration, and the MAC is an
+// HMAC-SHA256 of everything before it, truncated to cursorMACLength bytes.
+const (
+	cursorVersion           = 1
+	cursorFingerprintLength = 8
+	cursorMACLength         = 16
+)
+
+// Cursor marks a position in a naturally sorted listing: the sort key of the
+// last item returned, and its ID to break ties between equal keys
+type Cursor struct {
+	// SortKey is the external sort key of the last item
+	SortKey string
+	// ID is the unique ID of the last item, compared as the tie-break column
+	ID string
+}
+
+// CursorCodec creates, encodes and decodes keyset pagination cursors for
+// listings sorted by sort keys generated with a fixed configuration.
+// A CursorCodec is safe for concurrent use.
+type CursorCodec struct {
+	config      ExternalSortKeyConfig
+	fingerprint [cursorFingerprintLength]byte
+	secret      []byte
+}
+
+// NewCursorCodec creates a cursor codec for sort keys generated with the given
+// options. Cursors carry a fingerprint of the configuration and a MAC, so
+// decoding rejects corrupted cursors and cursors from other configurations.
+// With a secret, the MAC also prevents clients from forging cursors; with a
+// nil secret it only detects accidental changes.
+//
+// Returns an error if the configuration is invalid.
+//
+// Example:
+//
+//	codec, err := ansort.NewCursorCodec(secret, ansort.WithKeyScheme(ansort.KeySchemeOrdered))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	cursor, err := codec.Decode(r.URL.Query().Get("after"))
+//	if err != nil {
+//		http.Error(w, "bad cursor", http.StatusBadRequest)
+//		return
+//	}
+//	where, args := cursor.WhereClause("sort_key", "id")
+//	rows, err := db.Query("SELECT id, name, sort_key FROM files WHERE "+where+
+//		" ORDER BY sort_key, id LIMIT 50", args...)
+func NewCursorCodec(secret []byte, options ...ExternalSortKeyOption) (*CursorCodec, error) {
+	config := buildExternalSortKeyConfig(options...)
+	if err := validateExternalSortKeyConfig(config); err != nil {
+		return nil, err
+	}
+
+	return &CursorCodec{
+		config:      config,
+		fingerprint: sortKeyFingerprint(config),
+		secret:      append([]byte(nil), secret...),
+	}, nil
+}
+
+// CursorAfter returns the cursor positioned after the item with the given
+// input and ID
+func (c *CursorCodec) CursorAfter(input, id string) Cursor {
+	return Cursor{SortKey: generateSortKeyWithConfig(input, c.config), ID: id}
+}
+
+// Encode returns the cursor as an opaque URL-safe string
+func (c *CursorCodec) Encode(cursor Cursor) string {
+	payload := make([]byte, 0, 1+cursorFingerprintLength+binary.MaxVarintLen64+
+		len(cursor.SortKey)+len(cursor.ID)+cursorMACLength)
+	payload = append(payload, cursorVersion)
+	payload = append(payload, c.fingerprint[:]...)
+	payload = binary.AppendUvarint(payload, uint64(len(cursor.SortKey)))
+	payload = append(payload, cursor.SortKey...)
+	payload = append(payload, cursor.ID...)
+	payload = append(payload, c.mac(payload)...)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// Decode validates and decodes a cursor produced by Encode.
+//
+// Returns ErrInvalidCursor if the cursor is malformed or has been modified,
+// and ErrCursorMismatch if it was created with a different configuration.
+func (c *CursorCodec) Decode(token string) (Cursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(payload) < 1+cursorFingerprintLength+1+cursorMACLength {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	body, mac := payload[:len(payload)-cursorMACLength], payload[len(payload)-cursorMACLength:]
+	if !hmac.Equal(mac, c.mac(body)) || body[0] != cursorVersion {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if !bytes.Equal(body[1:1+cursorFingerprintLength], c.fingerprint[:]) {
+		return Cursor{}, ErrCursorMismatch
+	}
+
+	rest := body[1+cursorFingerprintLength:]
+	keyLength, n := binary.Uvarint(rest)
+	if n <= 0 || keyLength > uint64(len(rest)-n) {
+		return Cursor{}, ErrInvalidCursor
+	}
+	rest = rest[n:]
+	return Cursor{SortKey: string(rest[:keyLength]), ID: string(rest[keyLength:])}, nil
+}
+
+// mac returns the truncated HMAC-SHA256 of data
+func (c *CursorCodec) mac(data []byte) []byte {
+	h := hmac.New(sha256.New, c.secret)
+	h.Write(data)
+	return h.Sum(nil)[:cursorMACLength]
+}
+
+// WhereClause returns a SQL condition selecting the rows after the cursor,
+// for listings ordered by keyColumn and then idColumn, with '?' placeholders
+// and their arguments. Column names are inserted verbatim and must be trusted.
+// The key column must compare byte-wise, e.g. with a binary or "C" collation.
+//
+// Example:
+//
+//	where, args := cursor.WhereClause("sort_key", "id")
+//	// where: "(sort_key > ? OR (sort_key = ? AND id > ?))"
+//	// args:  []any{cursor.SortKey, cursor.SortKey, cursor.ID}
+func (c Cursor) WhereClause(keyColumn, idColumn string) (string, []any) {
+	return c.whereClause(keyColumn, idColumn, func(int) string { return "?" })
+}
+
+// WhereClauseNumbered is like WhereClause but uses numbered placeholders
+// ($1, $2, ...) starting at first, as PostgreSQL expects.
+//
+// Example:
+//
+//	where, args := cursor.WhereClauseNumbered("sort_key", "id", 2)
+//	// where: "(sort_key > $2 OR (sort_key = $3 AND id > $4))"
+func (c Cursor) WhereClauseNumbered(keyColumn, idColumn string, first int) (string, []any) {
+	return c.whereClause(keyColumn, idColumn, func(i int) string {
+		return "$" + strconv.Itoa(first+i)
+	})
+}
+
+// whereClause builds the keyset condition with the given placeholder style
+func (c Cursor) whereClause(keyColumn, idColumn string, placeholder func(int) string) (string, []any) {
+	where := "(" + keyColumn + " > " + placeholder(0) +
+		" OR (" + keyColumn + " = " + placeholder(1) +
+		" AND " + idColumn + " > " + placeholder(2) + "))"
+	return where, []any{c.SortKey, c.SortKey, c.ID}
+}
+
+// sortKeyFingerprint identifies the settings that affect generated keys
+func sortKeyFingerprint(config ExternalSortKeyConfig) [cursorFingerprintLength]byte {
+	descriptor := keyHeader(config)
+	if config.Header {
+		descriptor += "h"
+	}
+	sum := sha256.Sum256([]byte(descriptor))
+
+	var fingerprint [cursorFingerprintLength]byte
+	copy(fingerprint[:], sum[:])
+	return fingerprint
+}
@@ -0,0 +1,141 @@
+package ansort
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestCursorRoundTrip tests cursor creation, encoding and decoding
+func TestCursorRoundTrip(t *testing.T) {
+	codec, err := NewCursorCodec([]byte("secret"), WithKeyScheme(KeySchemeOrdered))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cursors := []Cursor{
+		codec.CursorAfter("file10.txt", "42"),
+		codec.CursorAfter("", ""),
+		{SortKey: "key\x00with\xffbytes", ID: "id-ü"},
+	}
+	for _, cursor := range cursors {
+		token := codec.Encode(cursor)
+		decoded, err := codec.Decode(token)
+		if err != nil {
+			t.Fatalf("Decode(%q) error: %v", token, err)
+		}
+		if decoded != cursor {
+			t.Errorf("Decode() = %+v, want %+v", decoded, cursor)
+		}
+	}
+
+	if got := codec.CursorAfter("file10.txt", "42").SortKey; got != ToNaturalSortKey("file10.txt", WithKeyScheme(KeySchemeOrdered)) {
+		t.Errorf("Cursor sort key %q does not match ToNaturalSortKey", got)
+	}
+}
+
+// TestCursorRejectsTampering tests rejection of modified and foreign cursors
+func TestCursorRejectsTampering(t *testing.T) {
+	codec, _ := NewCursorCodec([]byte("secret"))
+	token := codec.Encode(codec.CursorAfter("file10", "7"))
+
+	payload, _ := base64.RawURLEncoding.DecodeString(token)
+	for i := range payload {
+		modified := append([]byte(nil), payload...)
+		modified[i] ^= 0x01
+		if _, err := codec.Decode(base64.RawURLEncoding.EncodeToString(modified)); err != ErrInvalidCursor {
+			t.Errorf("Modified byte %d: error = %v, want ErrInvalidCursor", i, err)
+		}
+	}
+
+	for _, bad := range []string{"", "!!!", "AAAA", token[:len(token)-2]} {
+		if _, err := codec.Decode(bad); err != ErrInvalidCursor {
+			t.Errorf("Decode(%q) error = %v, want ErrInvalidCursor", bad, err)
+		}
+	}
+
+	otherSecret, _ := NewCursorCodec([]byte("other"))
+	if _, err := otherSecret.Decode(token); err != ErrInvalidCursor {
+		t.Errorf("Cursor with another secret: error = %v, want ErrInvalidCursor", err)
+	}
+
+	otherConfig, _ := NewCursorCodec([]byte("secret"), WithMaxNumericLength(20))
+	if _, err := otherConfig.Decode(token); err != ErrCursorMismatch {
+		t.Errorf("Cursor with another configuration: error = %v, want ErrCursorMismatch", err)
+	}
+
+	if _, err := NewCursorCodec(nil, WithMaxNumericLength(0)); err == nil {
+		t.Error("Expected error for invalid configuration")
+	}
+}
+
+// TestCursorWhereClause tests SQL bound generation
+func TestCursorWhereClause(t *testing.T) {
+	cursor := Cursor{SortKey: "k", ID: "9"}
+
+	where, args := cursor.WhereClause("sort_key", "id")
+	if where != "(sort_key > ? OR (sort_key = ? AND id > ?))" {
+		t.Errorf("WhereClause() = %q", where)
+	}
+	if !reflect.DeepEqual(args, []any{"k", "k", "9"}) {
+		t.Errorf("WhereClause() args = %v", args)
+	}
+
+	where, _ = cursor.WhereClauseNumbered("f.sort_key", "f.id", 3)
+	if where != "(f.sort_key > $3 OR (f.sort_key = $4 AND f.id > $5))" {
+		t.Errorf("WhereClauseNumbered() = %q", where)
+	}
+}
+
+// TestCursorPagination pages through a listing with cursors, emulating the SQL condition
+func TestCursorPagination(t *testing.T) {
+	codec, _ := NewCursorCodec(nil, WithExternalCaseInsensitive())
+
+	type row struct{ id, name, key string }
+	var rows []row
+	for i := 0; i < 23; i++ {
+		name := fmt.Sprintf("file%d", i%9)
+		rows = append(rows, row{id: fmt.Sprintf("%02d", i), name: name, key: ToNaturalSortKey(name, WithExternalCaseInsensitive())})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].key != rows[j].key {
+			return rows[i].key < rows[j].key
+		}
+		return rows[i].id < rows[j].id
+	})
+
+	var paged []row
+	token := ""
+	for page := 0; page < 10; page++ {
+		var after *Cursor
+		if token != "" {
+			cursor, err := codec.Decode(token)
+			if err != nil {
+				t.Fatalf("Decode error: %v", err)
+			}
+			after = &cursor
+		}
+
+		var results []row
+		for _, r := range rows {
+			if after == nil || r.key > after.SortKey || (r.key == after.SortKey && r.id > after.ID) {
+				results = append(results, r)
+			}
+			if len(results) == 5 {
+				break
+			}
+		}
+		if len(results) == 0 {
+			break
+		}
+		paged = append(paged, results...)
+		last := results[len(results)-1]
+		token = codec.Encode(codec.CursorAfter(last.name, last.id))
+	}
+
+	if !reflect.DeepEqual(paged, rows) {
+		t.Errorf("Pagination returned %d rows in a different order, want %d", len(paged), len(rows))
+	}
+}
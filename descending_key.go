@@ -0,0 +1,107 @@
+package ansort
+
+import (
+	"strings"
+)
+
+// Descending key alphabet
+//
+// Every byte of the ascending key is mapped to printable ASCII so that the
+// order of bytes is reversed, and the key is followed by descendingTerminator,
+// which sorts above every mapped byte. A key that is a prefix of another
+// therefore sorts after it, completing the reversal.
+//
+//   - Bytes '$'..'~' map to a single character in '"'..'|' (mirrored).
+//   - Bytes 0x7F..0xFF map to descendingHighEscape and two lowercase hex
+//     digits of 0xFF-b, placing them below every single character.
+//   - Bytes 0x00..'#' map to descendingLowEscape and the character '!'+('#'-b),
+//     placing them above every single character.
+const (
+	descendingSingleMin  = '$'
+	descendingSingleMax  = '~'
+	descendingMirror     = descendingSingleMin + '|'
+	descendingHighEscape = '!'
+	descendingLowEscape  = '}'
+	descendingTerminator = '~'
+	descendingHexDigits  = "0123456789abcdef"
+)
+
+// WithDescendingKey produces external sort keys whose plain ascending
+// lexicographic order is the reverse natural order. This suits stores that can
+// only scan ascending, such as DynamoDB sort keys. Keys are printable ASCII and
+// end with '~'. Works with every key scheme, lossless keys and binary keys.
+//
+// Example:
+//
+//	keys := ansort.ToNaturalSortKeys([]string{"file1", "file10", "file2"},
+//		ansort.WithDescendingKey())
+//	// Sorting keys ascending yields: file10, file2, file1
+func WithDescendingKey() ExternalSortKeyOption {
+	return func(c *ExternalSortKeyConfig) {
+		c.Descending = true
+	}
+}
+
+// descendingKey converts an ascending key into its descending form
+func descendingKey(key string) string {
+	return string(appendDescendingKey(make([]byte, 0, len(key)+1), key))
+}
+
+// appendDescendingKey appends the descending form of an ascending key to dst
+func appendDescendingKey(dst []byte, key string) []byte {
+	for i := 0; i < len(key); i++ {
+		b := key[i]
+		switch {
+		case b >= descendingSingleMin && b <= descendingSingleMax:
+			dst = append(dst, descendingMirror-b)
+		case b > descendingSingleMax:
+			v := 0xFF - b
+			dst = append(dst, descendingHighEscape, descendingHexDigits[v>>4], descendingHexDigits[v&0x0F])
+		default:
+			dst = append(dst, descendingLowEscape, '!'+(descendingSingleMin-1-b))
+		}
+	}
+	return append(dst, descendingTerminator)
+}
+
+// ascendingKey converts a descending key back into its ascending form
+// Returns ErrInvalidSortKey if the key is not a valid descending key.
+func ascendingKey(key string) (string, error) {
+	if !strings.HasSuffix(key, string(descendingTerminator)) {
+		return "", ErrInvalidSortKey
+	}
+	key = key[:len(key)-1]
+
+	result := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c == descendingHighEscape:
+			if i+2 >= len(key) {
+				return "", ErrInvalidSortKey
+			}
+			hi := strings.IndexByte(descendingHexDigits, key[i+1])
+			lo := strings.IndexByte(descendingHexDigits, key[i+2])
+			if hi < 0 || lo < 0 || hi<<4|lo > 0xFF-(descendingSingleMax+1) {
+				return "", ErrInvalidSortKey
+			}
+			result = append(result, byte(0xFF-(hi<<4|lo)))
+			i += 2
+		case c == descendingLowEscape:
+			if i+1 >= len(key) {
+				return "", ErrInvalidSortKey
+			}
+			v := key[i+1] - '!'
+			if key[i+1] < '!' || v > descendingSingleMin-1 {
+				return "", ErrInvalidSortKey
+			}
+			result = append(result, descendingSingleMin-1-v)
+			i++
+		case c >= descendingMirror-descendingSingleMax && c <= descendingMirror-descendingSingleMin:
+			result = append(result, descendingMirror-c)
+		default:
+			return "", ErrInvalidSortKey
+		}
+	}
+	return string(result), nil
+}
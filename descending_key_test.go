@@ -0,0 +1,112 @@
+package ansort
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestDescendingKey verifies that descending keys sort in reverse natural order
+func TestDescendingKey(t *testing.T) {
+	data := []string{"file1", "file10", "file2", "", "file", "File2"}
+	expected := []string{"file10", "file2", "file1", "file", "File2", ""}
+
+	keys := ToNaturalSortKeys(data, WithDescendingKey())
+	keyOf := make(map[string]string, len(data))
+	for i, item := range data {
+		keyOf[item] = keys[i]
+	}
+
+	sorted := make([]string, len(data))
+	copy(sorted, data)
+	sort.Slice(sorted, func(i, j int) bool { return keyOf[sorted[i]] < keyOf[sorted[j]] })
+	if !reflect.DeepEqual(sorted, expected) {
+		t.Errorf("Descending key order = %q, want %q", sorted, expected)
+	}
+
+	for i, key := range keys {
+		if single := ToNaturalSortKey(data[i], WithDescendingKey()); single != key {
+			t.Errorf("ToNaturalSortKey(%q) = %q, batch produced %q", data[i], single, key)
+		}
+		for j := 0; j < len(key); j++ {
+			if key[j] < '!' || key[j] > '~' {
+				t.Errorf("Descending key %q contains non-printable byte %#x", key, key[j])
+			}
+		}
+	}
+}
+
+// TestDescendingKeyReversesOrder checks reversal against ascending keys for random inputs
+func TestDescendingKeyReversesOrder(t *testing.T) {
+	configs := map[string][]ExternalSortKeyOption{
+		"padded":   nil,
+		"ordered":  {WithKeyScheme(KeySchemeOrdered)},
+		"lossless": {WithKeyScheme(KeySchemeOrdered), WithLosslessKey(), WithExternalCaseInsensitive()},
+	}
+
+	rng := rand.New(rand.NewSource(19))
+	for name, options := range configs {
+		t.Run(name, func(t *testing.T) {
+			descending := append([]ExternalSortKeyOption{WithDescendingKey()}, options...)
+			for i := 0; i < 3000; i++ {
+				a := randomKeyTestString(rng)
+				b := randomKeyTestString(rng)
+
+				ascendingOrder := strings.Compare(ToNaturalSortKey(a, options...), ToNaturalSortKey(b, options...))
+				descendingOrder := strings.Compare(ToNaturalSortKey(a, descending...), ToNaturalSortKey(b, descending...))
+				if descendingOrder != -ascendingOrder {
+					t.Fatalf("descending order %d is not the reverse of %d for %q vs %q", descendingOrder, ascendingOrder, a, b)
+				}
+			}
+		})
+	}
+
+	t.Run("binary", func(t *testing.T) {
+		for i := 0; i < 3000; i++ {
+			a := randomKeyTestString(rng)
+			b := randomKeyTestString(rng)
+
+			ascendingOrder := bytes.Compare(ToNaturalSortKeyBytes(a), ToNaturalSortKeyBytes(b))
+			descendingOrder := bytes.Compare(ToNaturalSortKeyBytes(a, WithDescendingKey()), ToNaturalSortKeyBytes(b, WithDescendingKey()))
+			if descendingOrder != -ascendingOrder {
+				t.Fatalf("descending order %d is not the reverse of %d for %q vs %q", descendingOrder, ascendingOrder, a, b)
+			}
+
+			decoded, err := DecodeNaturalSortKey(ToNaturalSortKeyBytes(a, WithDescendingKey()), WithDescendingKey())
+			if err != nil || decoded != a {
+				t.Fatalf("DecodeNaturalSortKey() = %q, %v; want %q", decoded, err, a)
+			}
+		}
+	})
+}
+
+// TestDescendingKeyDecoding tests that descending keys can be converted back
+func TestDescendingKeyDecoding(t *testing.T) {
+	rng := rand.New(rand.NewSource(23))
+	for i := 0; i < 1000; i++ {
+		raw := make([]byte, rng.Intn(12))
+		rng.Read(raw)
+		ascending, err := ascendingKey(descendingKey(string(raw)))
+		if err != nil || ascending != string(raw) {
+			t.Fatalf("ascendingKey(descendingKey(%x)) = %x, %v", raw, ascending, err)
+		}
+	}
+
+	for _, input := range []string{"", "File007", "a b\x00c"} {
+		key := ToNaturalSortKey(input, WithLosslessKey(), WithDescendingKey(), WithExternalCaseInsensitive())
+		decoded, err := FromNaturalSortKey(key)
+		if err != nil || decoded != input {
+			t.Errorf("FromNaturalSortKey(%q) = %q, %v; want %q", key, decoded, err, input)
+		}
+	}
+
+	for _, key := range []string{"abc", "!zz~", "}~", " ~"} {
+		if _, err := ascendingKey(key); !errors.Is(err, ErrInvalidSortKey) {
+			t.Errorf("ascendingKey(%q) error = %v, want ErrInvalidSortKey", key, err)
+		}
+	}
+}
@@ -0,0 +1,1061 @@
+package ansort
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ElasticsearchSortKey holds the Elasticsearch (or OpenSearch) definitions
+// needed to index natural sort keys with an ingest pipeline
+type ElasticsearchSortKey struct {
+	// Mapping is the body of a PUT <index>/_mapping request that declares the
+	// key field as a keyword, which sorts by UTF-8 bytes like the keys do
+	Mapping json.RawMessage
+	// Pipeline is the body of a PUT _ingest/pipeline/<id> request whose
+	// Painless script writes the key of the source field into the key field
+	Pipeline json.RawMessage
+}
+
+// painlessSortKeyParams are the script parameters passed to painlessSortKeySource.
+// Settings are reduced to the key format, so equivalent configurations
+// produce identical pipelines.
+type painlessSortKeyParams struct {
+	Field            string `json:"field"`
+	TargetField      string `json:"target_field"`
+	Scheme           string `json:"scheme"`
+	MaxNumericLength int    `json:"max_numeric_length"`
+	CaseSensitive    bool   `json:"case_sensitive"`
+	DecimalSeparator string `json:"decimal_separator"`
+	SignMode         int    `json:"sign_mode"`
+	Lossless         bool   `json:"lossless"`
+	Descending       bool   `json:"descending"`
+	Header           string `json:"header"`
+	MaxKeyBytes      int    `json:"max_key_bytes"`
+}
+
+// painlessSchemeNames maps each key scheme to its script parameter value
+var painlessSchemeNames = map[KeyScheme]string{
+	KeySchemePadded:  "padded",
+	KeySchemeOrdered: "ordered",
+	KeySchemeCompact: "compact",
+}
+
+// GenerateElasticsearchSortKey generates an index mapping for keyField and an
+// ingest pipeline that writes the natural sort key of sourceField into it.
+// The pipeline's Painless script produces the same keys as ToNaturalSortKey
+// with the given options, so documents indexed through the pipeline and keys
+// generated in Go can be mixed freely. Use VerifyPainlessConformance to check
+// a corpus offline.
+//
+// Both fields must be top-level fields. Documents whose source field is
+// missing or not a string are left unchanged. Keyword terms are limited to
+// 32766 bytes, so use WithMaxKeyBytes when inputs may be very long.
+//
+// Returns an error if a field name is empty or the configuration is invalid.
+//
+// Example:
+//
+//	es, err := ansort.GenerateElasticsearchSortKey("name", "name_sort",
+//		ansort.WithKeyScheme(ansort.KeySchemeOrdered))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	// PUT files/_mapping with es.Mapping
+//	// PUT _ingest/pipeline/natural-sort with es.Pipeline
+//	// Search with "sort": [{"name_sort": "asc"}]
+func GenerateElasticsearchSortKey(sourceField, keyField string, options ...ExternalSortKeyOption) (*ElasticsearchSortKey, error) {
+	if sourceField == "" {
+		return nil, &ValidationError{
+			Field:   "sourceField",
+			Message: "cannot be empty",
+		}
+	}
+	if keyField == "" {
+		return nil, &ValidationError{
+			Field:   "keyField",
+			Message: "cannot be empty",
+		}
+	}
+	config := buildExternalSortKeyConfig(options...)
+	if err := validateExternalSortKeyConfig(config); err != nil {
+		return nil, err
+	}
+
+	mapping, err := json.MarshalIndent(map[string]any{
+		"properties": map[string]any{
+			keyField: map[string]any{"type": "keyword"},
+		},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	params := newPainlessSortKeyParams(config)
+	params.Field = sourceField
+	params.TargetField = keyField
+	pipeline, err := json.MarshalIndent(map[string]any{
+		"description": "Natural sort key of " + sourceField + " in " + keyField,
+		"processors": []any{
+			map[string]any{
+				"script": map[string]any{
+					"lang":   "painless",
+					"source": painlessSortKeySource,
+					"params": params,
+				},
+			},
+		},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ElasticsearchSortKey{Mapping: mapping, Pipeline: pipeline}, nil
+}
+
+// newPainlessSortKeyParams returns the script parameters for a valid configuration
+func newPainlessSortKeyParams(config ExternalSortKeyConfig) painlessSortKeyParams {
+	format := config.keyFormat()
+	params := painlessSortKeyParams{
+		Scheme:           painlessSchemeNames[format.KeyScheme],
+		MaxNumericLength: format.MaxNumericLength,
+		CaseSensitive:    format.CaseSensitive,
+		SignMode:         int(format.SignMode),
+		Lossless:         format.Lossless,
+		Descending:       format.Descending,
+		MaxKeyBytes:      format.MaxKeyBytes,
+	}
+	if format.DecimalNumbers {
+		params.DecimalSeparator = string(format.DecimalSeparator)
+	}
+	if format.Header {
+		params.Header = keyHeader(config)
+	}
+	return params
+}
+
+// PainlessMismatch is an input whose Painless key differs from ToNaturalSortKey
+type PainlessMismatch struct {
+	// Input is the corpus entry
+	Input string
+	// Key is the key generated by ToNaturalSortKey
+	Key string
+	// PainlessKey is the key computed by the reference of the Painless script
+	PainlessKey string
+}
+
+// PainlessConformanceReport is the result of VerifyPainlessConformance
+type PainlessConformanceReport struct {
+	// Inputs is the number of corpus entries that were checked
+	Inputs int
+	// Mismatches lists every input whose keys differ
+	Mismatches []PainlessMismatch
+}
+
+// Conformant reports whether the Painless script matched every input
+func (r *PainlessConformanceReport) Conformant() bool {
+	return len(r.Mismatches) == 0
+}
+
+// VerifyPainlessConformance checks offline that the ingest pipeline generated
+// by GenerateElasticsearchSortKey produces the same keys as ToNaturalSortKey
+// for every corpus entry. It runs a Go reference of the Painless script,
+// translated statement by statement and fed with the same JSON parameters,
+// so no cluster is needed. Java and Go may ship different Unicode versions;
+// the reference follows Go's tables.
+//
+// Returns an error if the corpus is nil or contains invalid UTF-8 (which
+// JSON documents cannot hold), or the configuration is invalid.
+//
+// Example:
+//
+//	report, err := ansort.VerifyPainlessConformance(sampleNames,
+//		ansort.WithKeyScheme(ansort.KeySchemeOrdered))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, m := range report.Mismatches {
+//		fmt.Printf("%q: Go %q, Painless %q\n", m.Input, m.Key, m.PainlessKey)
+//	}
+func VerifyPainlessConformance(corpus []string, options ...ExternalSortKeyOption) (*PainlessConformanceReport, error) {
+	if err := validateSlice(corpus, "VerifyPainlessConformance"); err != nil {
+		return nil, err
+	}
+	for _, input := range corpus {
+		if !utf8.ValidString(input) {
+			return nil, &ValidationError{
+				Field:   "corpus",
+				Message: "must contain valid UTF-8 only",
+			}
+		}
+	}
+	config := buildExternalSortKeyConfig(options...)
+	if err := validateExternalSortKeyConfig(config); err != nil {
+		return nil, err
+	}
+
+	// Round-trip the parameters through JSON, as the pipeline does
+	encoded, err := json.Marshal(newPainlessSortKeyParams(config))
+	if err != nil {
+		return nil, err
+	}
+	var params painlessSortKeyParams
+	if err := json.Unmarshal(encoded, &params); err != nil {
+		return nil, err
+	}
+
+	report := &PainlessConformanceReport{Inputs: len(corpus)}
+	for _, input := range corpus {
+		key := generateSortKeyWithConfig(input, config)
+		if painlessKey := painlessNaturalSortKey(input, params); painlessKey != key {
+			report.Mismatches = append(report.Mismatches, PainlessMismatch{
+				Input:       input,
+				Key:         key,
+				PainlessKey: painlessKey,
+			})
+		}
+	}
+	return report, nil
+}
+
+// painlessSortKeySource is the ingest script. It only uses the String,
+// StringBuilder, Character, Integer and List methods allowed in Painless and
+// works on code points, so its keys encode to the same UTF-8 bytes as the Go
+// keys. Every function has a Go counterpart below with the same name prefixed
+// by "painless"; keep the two in sync.
+const painlessSortKeySource = `
+boolean isAsciiText(String s) {
+  for (int i = 0; i < s.length(); i++) {
+    if (s.charAt(i) >= 128) {
+      return false;
+    }
+  }
+  return true;
+}
+
+String normalizeDigits(String s) {
+  StringBuilder b = new StringBuilder();
+  int i = 0;
+  while (i < s.length()) {
+    int cp = s.codePointAt(i);
+    if (Character.isDigit(cp)) {
+      b.appendCodePoint(48 + Character.digit(cp, 10));
+    } else {
+      b.appendCodePoint(cp);
+    }
+    i += Character.charCount(cp);
+  }
+  return b.toString();
+}
+
+String foldCase(String s, boolean caseSensitive) {
+  if (caseSensitive) {
+    return s;
+  }
+  StringBuilder b = new StringBuilder();
+  int i = 0;
+  while (i < s.length()) {
+    int cp = s.codePointAt(i);
+    b.appendCodePoint(Character.toLowerCase(cp));
+    i += Character.charCount(cp);
+  }
+  return b.toString();
+}
+
+void tokenize(String s, List numeric, List values) {
+  int start = 0;
+  boolean inNumber = false;
+  int i = 0;
+  while (i < s.length()) {
+    int cp = s.codePointAt(i);
+    boolean digit = Character.isDigit(cp);
+    if (i == 0) {
+      inNumber = digit;
+    } else if (digit != inNumber) {
+      numeric.add(inNumber);
+      values.add(s.substring(start, i));
+      start = i;
+      inNumber = digit;
+    }
+    i += Character.charCount(cp);
+  }
+  if (start < s.length()) {
+    numeric.add(inNumber);
+    values.add(s.substring(start));
+  }
+}
+
+void mergeDecimals(List numeric, List values, String separator) {
+  List mergedNumeric = new ArrayList();
+  List mergedValues = new ArrayList();
+  int n = values.size();
+  for (int i = 0; i < n; i++) {
+    if (i + 2 < n && numeric.get(i) && !numeric.get(i + 1) && separator.equals(values.get(i + 1)) && numeric.get(i + 2)) {
+      mergedNumeric.add(true);
+      mergedValues.add(values.get(i) + separator + values.get(i + 2));
+      i += 2;
+    } else {
+      mergedNumeric.add(numeric.get(i));
+      mergedValues.add(values.get(i));
+    }
+  }
+  numeric.clear();
+  numeric.addAll(mergedNumeric);
+  values.clear();
+  values.addAll(mergedValues);
+}
+
+boolean isSignAt(List numeric, List values, int i, int mode) {
+  if (i + 1 >= values.size() || numeric.get(i) || !numeric.get(i + 1)) {
+    return false;
+  }
+  String alpha = values.get(i);
+  int last = alpha.charAt(alpha.length() - 1);
+  // '-' and '+'
+  if (last != 45 && last != 43) {
+    return false;
+  }
+  if (mode == 2) {
+    return true;
+  }
+  if (alpha.length() > 1) {
+    int prev = alpha.codePointBefore(alpha.length() - 1);
+    return !Character.isLetter(prev) && !Character.isDigit(prev);
+  }
+  return i == 0;
+}
+
+void attachSigns(List numeric, List values, int mode) {
+  List signedNumeric = new ArrayList();
+  List signedValues = new ArrayList();
+  int n = values.size();
+  for (int i = 0; i < n; i++) {
+    if (isSignAt(numeric, values, i, mode)) {
+      String alpha = values.get(i);
+      if (alpha.length() > 1) {
+        signedNumeric.add(false);
+        signedValues.add(alpha.substring(0, alpha.length() - 1));
+      }
+      signedNumeric.add(true);
+      signedValues.add(alpha.substring(alpha.length() - 1) + values.get(i + 1));
+      i++;
+    } else {
+      signedNumeric.add(numeric.get(i));
+      signedValues.add(values.get(i));
+    }
+  }
+  numeric.clear();
+  numeric.addAll(signedNumeric);
+  values.clear();
+  values.addAll(signedValues);
+}
+
+String trimLeftZeros(String s) {
+  int i = 0;
+  while (i < s.length() && s.charAt(i) == 48) {
+    i++;
+  }
+  return s.substring(i);
+}
+
+String trimRightZeros(String s) {
+  int end = s.length();
+  while (end > 0 && s.charAt(end - 1) == 48) {
+    end--;
+  }
+  return s.substring(0, end);
+}
+
+String padLeft(String digits, int width) {
+  StringBuilder b = new StringBuilder();
+  for (int i = digits.length(); i < width; i++) {
+    b.append('0');
+  }
+  b.append(digits);
+  return b.toString();
+}
+
+String padRight(String digits, int width) {
+  StringBuilder b = new StringBuilder();
+  b.append(digits);
+  for (int i = digits.length(); i < width; i++) {
+    b.append('0');
+  }
+  return b.toString();
+}
+
+String integerPart(String magnitude, String separator) {
+  int idx = separator.isEmpty() ? -1 : magnitude.indexOf(separator);
+  return idx < 0 ? magnitude : magnitude.substring(0, idx);
+}
+
+String fractionPart(String magnitude, String separator) {
+  int idx = separator.isEmpty() ? -1 : magnitude.indexOf(separator);
+  return idx < 0 ? '' : magnitude.substring(idx + separator.length());
+}
+
+boolean hasSign(String value) {
+  return value.length() > 0 && (value.charAt(0) == 45 || value.charAt(0) == 43);
+}
+
+boolean isNegative(String value) {
+  return value.length() > 0 && value.charAt(0) == 45;
+}
+
+String unsigned(String value) {
+  return hasSign(value) ? value.substring(1) : value;
+}
+
+boolean isZeroMagnitude(String magnitude, String separator) {
+  return trimLeftZeros(integerPart(magnitude, separator)).isEmpty() && trimRightZeros(fractionPart(magnitude, separator)).isEmpty();
+}
+
+String complement(String s, int base) {
+  StringBuilder b = new StringBuilder();
+  for (int i = 0; i < s.length(); i++) {
+    b.appendCodePoint(base - s.charAt(i));
+  }
+  return b.toString();
+}
+
+void orderedText(StringBuilder b, String text) {
+  for (int i = 0; i < text.length(); i++) {
+    int c = text.charAt(i);
+    if (c <= 33) {
+      b.append('!');
+      b.appendCodePoint(c + 35);
+    } else {
+      b.appendCodePoint(c);
+    }
+  }
+  b.append('!"');
+}
+
+void orderedLength(StringBuilder b, int n) {
+  String digits = Integer.toString(n);
+  b.appendCodePoint(48 + digits.length());
+  b.append(digits);
+}
+
+void paddedNumber(StringBuilder b, String value, Map p) {
+  String separator = p.decimal_separator;
+  int width = p.max_numeric_length;
+  String normalized = normalizeDigits(value);
+  if (separator.isEmpty() && p.sign_mode == 0) {
+    b.append(padLeft(normalized, width));
+    return;
+  }
+  String magnitude = unsigned(normalized);
+  boolean negative = isNegative(normalized) && !isZeroMagnitude(magnitude, separator);
+  String digits = padLeft(integerPart(magnitude, separator), width);
+  String fraction = '';
+  if (!separator.isEmpty()) {
+    fraction = padRight(trimRightZeros(fractionPart(magnitude, separator)), width);
+  }
+  if (negative) {
+    b.append('-');
+    // '0' + '9' mirrors the digits
+    digits = complement(digits, 105);
+    fraction = complement(fraction, 105);
+  }
+  b.append(digits);
+  if (!separator.isEmpty()) {
+    b.append(separator);
+    b.append(fraction);
+    if (negative) {
+      b.append(':');
+    }
+  }
+}
+
+void orderedNumber(StringBuilder b, String value, Map p) {
+  String separator = p.decimal_separator;
+  boolean signed = p.sign_mode != 0;
+  String normalized = normalizeDigits(value);
+  String magnitude = signed ? unsigned(normalized) : normalized;
+  boolean negative = signed && isNegative(normalized) && !isZeroMagnitude(magnitude, separator);
+  String whole = trimLeftZeros(integerPart(magnitude, separator));
+  StringBuilder encoded = new StringBuilder();
+  orderedLength(encoded, whole.length());
+  encoded.append(whole);
+  if (!separator.isEmpty()) {
+    encoded.append(trimRightZeros(fractionPart(magnitude, separator)));
+    encoded.append('!');
+  }
+  if (signed && negative) {
+    b.append('n');
+    // '!' + '~' mirrors printable ASCII
+    b.append(complement(encoded.toString(), 159));
+  } else if (signed) {
+    b.append('p');
+    b.append(encoded.toString());
+  } else {
+    b.append(encoded.toString());
+  }
+  orderedLength(b, normalized.length());
+  if (!separator.isEmpty() || signed || !isAsciiText(value)) {
+    b.append('3');
+    orderedText(b, value);
+  }
+}
+
+void compactNumber(StringBuilder b, String value, Map p) {
+  String separator = p.decimal_separator;
+  String magnitude = normalizeDigits(value);
+  boolean negative = false;
+  if (p.sign_mode != 0) {
+    negative = isNegative(magnitude);
+    magnitude = unsigned(magnitude);
+  }
+  negative = negative && !isZeroMagnitude(magnitude, separator);
+  String whole = trimLeftZeros(integerPart(magnitude, separator));
+  StringBuilder encoded = new StringBuilder();
+  int n = whole.length();
+  while (n >= 9) {
+    encoded.append('9');
+    n -= 9;
+  }
+  encoded.appendCodePoint(48 + n);
+  encoded.append(whole);
+  if (!separator.isEmpty()) {
+    encoded.append(trimRightZeros(fractionPart(magnitude, separator)));
+    encoded.append('/');
+  }
+  if (negative) {
+    b.append('-');
+    b.append(complement(encoded.toString(), 105));
+  } else {
+    b.append(encoded.toString());
+  }
+}
+
+int utf8Length(int cp) {
+  if (cp < 128) {
+    return 1;
+  }
+  if (cp < 2048) {
+    return 2;
+  }
+  return cp < 65536 ? 3 : 4;
+}
+
+void descendingByte(StringBuilder b, int v) {
+  if (v >= 36 && v <= 126) {
+    b.appendCodePoint(160 - v);
+  } else if (v > 126) {
+    int x = 255 - v;
+    b.append('!');
+    b.append('0123456789abcdef'.substring(x >> 4, (x >> 4) + 1));
+    b.append('0123456789abcdef'.substring(x & 15, (x & 15) + 1));
+  } else {
+    b.append('}');
+    b.appendCodePoint(68 - v);
+  }
+}
+
+String descendingKey(String key) {
+  StringBuilder b = new StringBuilder();
+  int i = 0;
+  while (i < key.length()) {
+    int cp = key.codePointAt(i);
+    int size = utf8Length(cp);
+    if (size == 1) {
+      descendingByte(b, cp);
+    } else if (size == 2) {
+      descendingByte(b, 192 | (cp >> 6));
+      descendingByte(b, 128 | (cp & 63));
+    } else if (size == 3) {
+      descendingByte(b, 224 | (cp >> 12));
+      descendingByte(b, 128 | ((cp >> 6) & 63));
+      descendingByte(b, 128 | (cp & 63));
+    } else {
+      descendingByte(b, 240 | (cp >> 18));
+      descendingByte(b, 128 | ((cp >> 12) & 63));
+      descendingByte(b, 128 | ((cp >> 6) & 63));
+      descendingByte(b, 128 | (cp & 63));
+    }
+    i += Character.charCount(cp);
+  }
+  b.append('~');
+  return b.toString();
+}
+
+String truncateKey(String key, int maxBytes) {
+  int total = 0;
+  int i = 0;
+  while (i < key.length()) {
+    int cp = key.codePointAt(i);
+    total += utf8Length(cp);
+    i += Character.charCount(cp);
+  }
+  if (total <= maxBytes) {
+    return key;
+  }
+  StringBuilder b = new StringBuilder();
+  int used = 0;
+  i = 0;
+  while (used < maxBytes) {
+    int cp = key.codePointAt(i);
+    int size = utf8Length(cp);
+    if (used + size > maxBytes) {
+      int rest = maxBytes - used;
+      b.appendCodePoint(rest == 1 ? 127 : (rest == 2 ? 2047 : 65535));
+      break;
+    }
+    b.appendCodePoint(cp);
+    used += size;
+    i += Character.charCount(cp);
+  }
+  return b.toString();
+}
+
+String naturalSortKey(String input, Map p) {
+  String key = '';
+  if (!input.isEmpty()) {
+    List numeric = new ArrayList();
+    List values = new ArrayList();
+    tokenize(input, numeric, values);
+    String separator = p.decimal_separator;
+    if (!separator.isEmpty()) {
+      mergeDecimals(numeric, values, separator);
+    }
+    int signMode = p.sign_mode;
+    if (signMode != 0) {
+      attachSigns(numeric, values, signMode);
+    }
+    String scheme = p.scheme;
+    boolean caseSensitive = p.case_sensitive;
+    StringBuilder b = new StringBuilder();
+    for (int i = 0; i < values.size(); i++) {
+      String value = values.get(i);
+      if (numeric.get(i)) {
+        if (scheme.equals('ordered')) {
+          b.append('1');
+          orderedNumber(b, value, p);
+        } else if (scheme.equals('compact')) {
+          compactNumber(b, value, p);
+        } else {
+          paddedNumber(b, value, p);
+        }
+      } else if (scheme.equals('ordered')) {
+        b.append('2');
+        orderedText(b, foldCase(value, caseSensitive));
+      } else {
+        b.append(foldCase(value, caseSensitive));
+      }
+    }
+    if (p.lossless) {
+      b.append(' ');
+      orderedText(b, input);
+    }
+    key = b.toString();
+  }
+  if (p.descending) {
+    key = descendingKey(key);
+  }
+  String header = p.header;
+  key = header + key;
+  int maxKeyBytes = p.max_key_bytes;
+  if (maxKeyBytes > 0) {
+    key = truncateKey(key, maxKeyBytes);
+  }
+  return key;
+}
+
+def value = ctx[params.field];
+if (value instanceof String) {
+  ctx[params.target_field] = naturalSortKey((String) value, params);
+}
+`
+
+// The functions below are the Go reference of painlessSortKeySource, used by
+// VerifyPainlessConformance. They deliberately mirror the script rather than
+// reuse the key generators, so that a divergence between the two shows up as
+// a mismatch. Java's Character methods map to their unicode package
+// equivalents, and both iterate by code point.
+
+// painlessIsAsciiText mirrors isAsciiText
+func painlessIsAsciiText(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 128 {
+			return false
+		}
+	}
+	return true
+}
+
+// painlessNormalizeDigits mirrors normalizeDigits
+func painlessNormalizeDigits(s string) string {
+	var b strings.Builder
+	for _, cp := range s {
+		if unicode.IsDigit(cp) {
+			b.WriteRune(rune('0' + digitValue(cp)))
+		} else {
+			b.WriteRune(cp)
+		}
+	}
+	return b.String()
+}
+
+// painlessFoldCase mirrors foldCase
+func painlessFoldCase(s string, caseSensitive bool) string {
+	if caseSensitive {
+		return s
+	}
+	var b strings.Builder
+	for _, cp := range s {
+		b.WriteRune(unicode.ToLower(cp))
+	}
+	return b.String()
+}
+
+// painlessTokenize mirrors tokenize
+func painlessTokenize(s string) (numeric []bool, values []string) {
+	start := 0
+	inNumber := false
+	for i, cp := range s {
+		digit := unicode.IsDigit(cp)
+		if i == 0 {
+			inNumber = digit
+		} else if digit != inNumber {
+			numeric = append(numeric, inNumber)
+			values = append(values, s[start:i])
+			start = i
+			inNumber = digit
+		}
+	}
+	if start < len(s) {
+		numeric = append(numeric, inNumber)
+		values = append(values, s[start:])
+	}
+	return numeric, values
+}
+
+// painlessMergeDecimals mirrors mergeDecimals
+func painlessMergeDecimals(numeric []bool, values []string, separator string) ([]bool, []string) {
+	var mergedNumeric []bool
+	var mergedValues []string
+	n := len(values)
+	for i := 0; i < n; i++ {
+		if i+2 < n && numeric[i] && !numeric[i+1] && separator == values[i+1] && numeric[i+2] {
+			mergedNumeric = append(mergedNumeric, true)
+			mergedValues = append(mergedValues, values[i]+separator+values[i+2])
+			i += 2
+		} else {
+			mergedNumeric = append(mergedNumeric, numeric[i])
+			mergedValues = append(mergedValues, values[i])
+		}
+	}
+	return mergedNumeric, mergedValues
+}
+
+// painlessIsSignAt mirrors isSignAt
+func painlessIsSignAt(numeric []bool, values []string, i int, mode int) bool {
+	if i+1 >= len(values) || numeric[i] || !numeric[i+1] {
+		return false
+	}
+	alpha := values[i]
+	last := alpha[len(alpha)-1]
+	if last != '-' && last != '+' {
+		return false
+	}
+	if mode == int(SignAlways) {
+		return true
+	}
+	if len(alpha) > 1 {
+		prev, _ := utf8.DecodeLastRuneInString(alpha[:len(alpha)-1])
+		return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+	}
+	return i == 0
+}
+
+// painlessAttachSigns mirrors attachSigns
+func painlessAttachSigns(numeric []bool, values []string, mode int) ([]bool, []string) {
+	var signedNumeric []bool
+	var signedValues []string
+	n := len(values)
+	for i := 0; i < n; i++ {
+		if painlessIsSignAt(numeric, values, i, mode) {
+			alpha := values[i]
+			if len(alpha) > 1 {
+				signedNumeric = append(signedNumeric, false)
+				signedValues = append(signedValues, alpha[:len(alpha)-1])
+			}
+			signedNumeric = append(signedNumeric, true)
+			signedValues = append(signedValues, alpha[len(alpha)-1:]+values[i+1])
+			i++
+		} else {
+			signedNumeric = append(signedNumeric, numeric[i])
+			signedValues = append(signedValues, values[i])
+		}
+	}
+	return signedNumeric, signedValues
+}
+
+// painlessPad mirrors padLeft and padRight
+func painlessPad(digits string, width int, left bool) string {
+	if len(digits) >= width {
+		return digits
+	}
+	padding := strings.Repeat("0", width-len(digits))
+	if left {
+		return padding + digits
+	}
+	return digits + padding
+}
+
+// painlessSplitDecimal mirrors integerPart and fractionPart
+func painlessSplitDecimal(magnitude, separator string) (integer, fraction string) {
+	idx := -1
+	if separator != "" {
+		idx = strings.Index(magnitude, separator)
+	}
+	if idx < 0 {
+		return magnitude, ""
+	}
+	return magnitude[:idx], magnitude[idx+len(separator):]
+}
+
+// painlessUnsigned mirrors hasSign, isNegative and unsigned
+func painlessUnsigned(value string) (negative bool, magnitude string) {
+	if len(value) > 0 && (value[0] == '-' || value[0] == '+') {
+		return value[0] == '-', value[1:]
+	}
+	return false, value
+}
+
+// painlessIsZeroMagnitude mirrors isZeroMagnitude, trimLeftZeros and trimRightZeros
+func painlessIsZeroMagnitude(magnitude, separator string) bool {
+	integer, fraction := painlessSplitDecimal(magnitude, separator)
+	return strings.TrimLeft(integer, "0") == "" && strings.TrimRight(fraction, "0") == ""
+}
+
+// painlessComplement mirrors complement
+func painlessComplement(s string, base int) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		b.WriteRune(rune(base - int(s[i])))
+	}
+	return b.String()
+}
+
+// painlessOrderedText mirrors orderedText
+func painlessOrderedText(b *strings.Builder, text string) {
+	for i := 0; i < len(text); i++ {
+		if c := text[i]; c <= 33 {
+			b.WriteByte('!')
+			b.WriteByte(c + 35)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	b.WriteString("!\"")
+}
+
+// painlessOrderedLength mirrors orderedLength
+func painlessOrderedLength(b *strings.Builder, n int) {
+	digits := strconv.Itoa(n)
+	b.WriteRune(rune('0' + len(digits)))
+	b.WriteString(digits)
+}
+
+// painlessPaddedNumber mirrors paddedNumber
+func painlessPaddedNumber(b *strings.Builder, value string, p painlessSortKeyParams) {
+	separator := p.DecimalSeparator
+	normalized := painlessNormalizeDigits(value)
+	if separator == "" && p.SignMode == 0 {
+		b.WriteString(painlessPad(normalized, p.MaxNumericLength, true))
+		return
+	}
+	negative, magnitude := painlessUnsigned(normalized)
+	negative = negative && !painlessIsZeroMagnitude(magnitude, separator)
+	integer, fraction := painlessSplitDecimal(magnitude, separator)
+	digits := painlessPad(integer, p.MaxNumericLength, true)
+	if separator != "" {
+		fraction = painlessPad(strings.TrimRight(fraction, "0"), p.MaxNumericLength, false)
+	} else {
+		fraction = ""
+	}
+	if negative {
+		b.WriteByte('-')
+		digits = painlessComplement(digits, '0'+'9')
+		fraction = painlessComplement(fraction, '0'+'9')
+	}
+	b.WriteString(digits)
+	if separator != "" {
+		b.WriteString(separator)
+		b.WriteString(fraction)
+		if negative {
+			b.WriteByte(':')
+		}
+	}
+}
+
+// painlessOrderedNumber mirrors orderedNumber
+func painlessOrderedNumber(b *strings.Builder, value string, p painlessSortKeyParams) {
+	separator := p.DecimalSeparator
+	signed := p.SignMode != 0
+	normalized := painlessNormalizeDigits(value)
+	negative, magnitude := false, normalized
+	if signed {
+		negative, magnitude = painlessUnsigned(normalized)
+	}
+	negative = negative && !painlessIsZeroMagnitude(magnitude, separator)
+	integer, fraction := painlessSplitDecimal(magnitude, separator)
+	whole := strings.TrimLeft(integer, "0")
+
+	var encoded strings.Builder
+	painlessOrderedLength(&encoded, len(whole))
+	encoded.WriteString(whole)
+	if separator != "" {
+		encoded.WriteString(strings.TrimRight(fraction, "0"))
+		encoded.WriteByte('!')
+	}
+	switch {
+	case signed && negative:
+		b.WriteByte('n')
+		b.WriteString(painlessComplement(encoded.String(), '!'+'~'))
+	case signed:
+		b.WriteByte('p')
+		b.WriteString(encoded.String())
+	default:
+		b.WriteString(encoded.String())
+	}
+	painlessOrderedLength(b, len(normalized))
+	if separator != "" || signed || !painlessIsAsciiText(value) {
+		b.WriteByte('3')
+		painlessOrderedText(b, value)
+	}
+}
+
+// painlessCompactNumber mirrors compactNumber
+func painlessCompactNumber(b *strings.Builder, value string, p painlessSortKeyParams) {
+	separator := p.DecimalSeparator
+	magnitude := painlessNormalizeDigits(value)
+	negative := false
+	if p.SignMode != 0 {
+		negative, magnitude = painlessUnsigned(magnitude)
+	}
+	negative = negative && !painlessIsZeroMagnitude(magnitude, separator)
+	integer, fraction := painlessSplitDecimal(magnitude, separator)
+	whole := strings.TrimLeft(integer, "0")
+
+	var encoded strings.Builder
+	n := len(whole)
+	for n >= 9 {
+		encoded.WriteByte('9')
+		n -= 9
+	}
+	encoded.WriteRune(rune('0' + n))
+	encoded.WriteString(whole)
+	if separator != "" {
+		encoded.WriteString(strings.TrimRight(fraction, "0"))
+		encoded.WriteByte('/')
+	}
+	if negative {
+		b.WriteByte('-')
+		b.WriteString(painlessComplement(encoded.String(), '0'+'9'))
+	} else {
+		b.WriteString(encoded.String())
+	}
+}
+
+// painlessDescendingByte mirrors descendingByte
+func painlessDescendingByte(b *strings.Builder, v int) {
+	const hexDigits = "0123456789abcdef"
+	switch {
+	case v >= 36 && v <= 126:
+		b.WriteRune(rune(160 - v))
+	case v > 126:
+		x := 255 - v
+		b.WriteByte('!')
+		b.WriteByte(hexDigits[x>>4])
+		b.WriteByte(hexDigits[x&15])
+	default:
+		b.WriteByte('}')
+		b.WriteRune(rune(68 - v))
+	}
+}
+
+// painlessDescendingKey mirrors descendingKey
+func painlessDescendingKey(key string) string {
+	var b strings.Builder
+	for _, cp := range key {
+		var encoded [utf8.UTFMax]byte
+		size := utf8.EncodeRune(encoded[:], cp)
+		for _, v := range encoded[:size] {
+			painlessDescendingByte(&b, int(v))
+		}
+	}
+	b.WriteByte('~')
+	return b.String()
+}
+
+// painlessTruncateKey mirrors truncateKey and utf8Length
+func painlessTruncateKey(key string, maxBytes int) string {
+	if len(key) <= maxBytes {
+		return key
+	}
+	var b strings.Builder
+	used := 0
+	for _, cp := range key {
+		if used >= maxBytes {
+			break
+		}
+		size := utf8.RuneLen(cp)
+		if used+size > maxBytes {
+			b.WriteRune(largestRuneOfLength(maxBytes - used))
+			break
+		}
+		b.WriteRune(cp)
+		used += size
+	}
+	return b.String()
+}
+
+// painlessNaturalSortKey mirrors naturalSortKey
+func painlessNaturalSortKey(input string, p painlessSortKeyParams) string {
+	key := ""
+	if input != "" {
+		numeric, values := painlessTokenize(input)
+		if p.DecimalSeparator != "" {
+			numeric, values = painlessMergeDecimals(numeric, values, p.DecimalSeparator)
+		}
+		if p.SignMode != 0 {
+			numeric, values = painlessAttachSigns(numeric, values, p.SignMode)
+		}
+
+		var b strings.Builder
+		for i, value := range values {
+			switch {
+			case numeric[i] && p.Scheme == "ordered":
+				b.WriteByte('1')
+				painlessOrderedNumber(&b, value, p)
+			case numeric[i] && p.Scheme == "compact":
+				painlessCompactNumber(&b, value, p)
+			case numeric[i]:
+				painlessPaddedNumber(&b, value, p)
+			case p.Scheme == "ordered":
+				b.WriteByte('2')
+				painlessOrderedText(&b, painlessFoldCase(value, p.CaseSensitive))
+			default:
+				b.WriteString(painlessFoldCase(value, p.CaseSensitive))
+			}
+		}
+		if p.Lossless {
+			b.WriteByte(' ')
+			painlessOrderedText(&b, input)
+		}
+		key = b.String()
+	}
+	if p.Descending {
+		key = painlessDescendingKey(key)
+	}
+	key = p.Header + key
+	if p.MaxKeyBytes > 0 {
+		key = painlessTruncateKey(key, p.MaxKeyBytes)
+	}
+	return key
+}
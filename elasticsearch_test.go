@@ -0,0 +1,213 @@
+package ansort
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// painlessCorpus covers every tokenization and number encoding path
+var painlessCorpus = []string{
+	"", "a", "file1", "file10", "File2.txt", "file007", "007", "12345678901234567890123",
+	"v1.2.3", "3.14", "3,14", "2.50", "-5", "+5", "a-5", "a -5", "temp-10", "x--3", "-0", "-0.0",
+	"-1.5", "-", "+", "1-2", "1.2.3", "..1", "a b", "a\tb", "a!b", "a\"b", "a#b", "a$b", "~",
+	"Ärger 7", "ÄRGER 7", "İstanbul 3", "ΟΔΟΣ 2", "日本語12", "٣٤ item", "१२३", "１２３", "file１0",
+	"𝟘𝟙 math", "😀 9", "emoji😀10", "MiXeD CaSe 0042", "z\x7f", "߿ࠀ", "￿ 1",
+	"offset-10 and +3.25 and -0,5",
+}
+
+// painlessConfigs are option sets covering every script parameter
+var painlessConfigs = [][]ExternalSortKeyOption{
+	nil,
+	{WithExternalCaseInsensitive()},
+	{WithMaxNumericLength(3)},
+	{WithExternalDecimalNumbers('.')},
+	{WithExternalDecimalNumbers(','), WithExternalSignedNumbers(SignAlways)},
+	{WithExternalSignedNumbers(SignStandalone), WithExternalCaseInsensitive()},
+	{WithExternalDecimalNumbers('.'), WithExternalSignedNumbers(SignStandalone)},
+	{WithKeyScheme(KeySchemeOrdered)},
+	{WithKeyScheme(KeySchemeOrdered), WithExternalDecimalNumbers('.'), WithExternalSignedNumbers(SignAlways)},
+	{WithKeyScheme(KeySchemeOrdered), WithExternalCaseInsensitive(), WithExternalCaseTieBreak()},
+	{WithKeyScheme(KeySchemeCompact)},
+	{WithKeyScheme(KeySchemeCompact), WithExternalDecimalNumbers('.'), WithExternalSignedNumbers(SignStandalone)},
+	{WithLosslessKey(), WithExternalCaseInsensitive()},
+	{WithDescendingKey()},
+	{WithKeyScheme(KeySchemeOrdered), WithLosslessKey(), WithDescendingKey()},
+	{WithKeyHeader(), WithKeyScheme(KeySchemeCompact)},
+	{WithMaxKeyBytes(6)},
+	{WithMaxKeyBytes(7), WithKeyScheme(KeySchemeOrdered), WithLosslessKey()},
+	{WithKeyHeader(), WithMaxKeyBytes(20), WithDescendingKey()},
+}
+
+// TestPainlessConformance tests the Painless reference against ToNaturalSortKey
+func TestPainlessConformance(t *testing.T) {
+	for i, options := range painlessConfigs {
+		report, err := VerifyPainlessConformance(painlessCorpus, options...)
+		if err != nil {
+			t.Fatalf("Config %d: unexpected error: %v", i, err)
+		}
+		if report.Inputs != len(painlessCorpus) {
+			t.Errorf("Config %d: Inputs = %d, want %d", i, report.Inputs, len(painlessCorpus))
+		}
+		for _, m := range report.Mismatches {
+			t.Errorf("Config %d: %q: Go %q, Painless %q", i, m.Input, m.Key, m.PainlessKey)
+		}
+	}
+}
+
+// TestPainlessConformanceRandom tests random inputs built from tricky characters
+func TestPainlessConformanceRandom(t *testing.T) {
+	alphabet := []rune("0123456789aZ.,-+ !\"#$~\x7fÄß٣１😀ࠀ")
+	rng := rand.New(rand.NewSource(1))
+	corpus := make([]string, 2000)
+	for i := range corpus {
+		runes := make([]rune, rng.Intn(12))
+		for j := range runes {
+			runes[j] = alphabet[rng.Intn(len(alphabet))]
+		}
+		corpus[i] = string(runes)
+	}
+
+	for i, options := range painlessConfigs {
+		report, err := VerifyPainlessConformance(corpus, options...)
+		if err != nil {
+			t.Fatalf("Config %d: unexpected error: %v", i, err)
+		}
+		if !report.Conformant() {
+			m := report.Mismatches[0]
+			t.Errorf("Config %d: %d mismatches, first %q: Go %q, Painless %q",
+				i, len(report.Mismatches), m.Input, m.Key, m.PainlessKey)
+		}
+	}
+}
+
+// TestPainlessReferenceDetectsDivergence tests that the harness reports mismatches
+func TestPainlessReferenceDetectsDivergence(t *testing.T) {
+	config := buildExternalSortKeyConfig()
+	params := newPainlessSortKeyParams(config)
+	params.MaxNumericLength = 5
+	if got := painlessNaturalSortKey("file10", params); got == generateSortKeyWithConfig("file10", config) {
+		t.Errorf("Different parameters produced the same key %q", got)
+	}
+}
+
+// TestPainlessSourceMatchesReference tests that every script function has a Go mirror
+func TestPainlessSourceMatchesReference(t *testing.T) {
+	source, err := os.ReadFile("elasticsearch.go")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	mirrors := map[string]bool{}
+	for _, match := range regexp.MustCompile(`// painless\w+ mirrors ([\w, ]+)\n`).FindAllSubmatch(source, -1) {
+		for _, name := range regexp.MustCompile(`\w+`).FindAll(match[1], -1) {
+			mirrors[string(name)] = true
+		}
+	}
+
+	declared := regexp.MustCompile(`(?m)^(?:boolean|String|void|int) (\w+)\(`).FindAllStringSubmatch(painlessSortKeySource, -1)
+	if len(declared) == 0 {
+		t.Fatal("No functions found in the Painless source")
+	}
+	for _, function := range declared {
+		if !mirrors[function[1]] {
+			t.Errorf("Painless function %s has no Go mirror", function[1])
+		}
+	}
+}
+
+// TestGenerateElasticsearchSortKey tests the generated mapping and pipeline
+func TestGenerateElasticsearchSortKey(t *testing.T) {
+	es, err := GenerateElasticsearchSortKey("name", "name_sort",
+		WithKeyScheme(KeySchemeOrdered), WithExternalDecimalNumbers(','), WithKeyHeader())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var mapping struct {
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(es.Mapping, &mapping); err != nil {
+		t.Fatalf("Mapping is not valid JSON: %v", err)
+	}
+	if got := mapping.Properties["name_sort"]["type"]; got != "keyword" {
+		t.Errorf("Key field type = %v, want keyword", got)
+	}
+
+	var pipeline struct {
+		Processors []struct {
+			Script struct {
+				Lang   string                `json:"lang"`
+				Source string                `json:"source"`
+				Params painlessSortKeyParams `json:"params"`
+			} `json:"script"`
+		} `json:"processors"`
+	}
+	if err := json.Unmarshal(es.Pipeline, &pipeline); err != nil {
+		t.Fatalf("Pipeline is not valid JSON: %v", err)
+	}
+	if len(pipeline.Processors) != 1 {
+		t.Fatalf("Got %d processors, want 1", len(pipeline.Processors))
+	}
+	script := pipeline.Processors[0].Script
+	if script.Lang != "painless" || script.Source != painlessSortKeySource {
+		t.Errorf("Unexpected script %q", script.Lang)
+	}
+	want := painlessSortKeyParams{
+		Field:            "name",
+		TargetField:      "name_sort",
+		Scheme:           "ordered",
+		CaseSensitive:    true,
+		DecimalSeparator: ",",
+		Header:           "nsk1:o00s,0-a;",
+	}
+	if script.Params != want {
+		t.Errorf("Params = %+v, want %+v", script.Params, want)
+	}
+
+	// Keys computed from the shipped parameters match Go keys
+	script.Params.Field, script.Params.TargetField = "", ""
+	input := "Price 3,50 EUR"
+	if got, want := painlessNaturalSortKey(input, script.Params), ToNaturalSortKey(input,
+		WithKeyScheme(KeySchemeOrdered), WithExternalDecimalNumbers(','), WithKeyHeader()); got != want {
+		t.Errorf("Painless key %q, want %q", got, want)
+	}
+}
+
+// TestPainlessSourceUsesSupportedSyntax tests for Java constructs Painless rejects
+func TestPainlessSourceUsesSupportedSyntax(t *testing.T) {
+	for _, construct := range []string{"->", "::", "final ", "char ", "Locale", "toLowerCase()", ".repeat("} {
+		if strings.Contains(painlessSortKeySource, construct) {
+			t.Errorf("Painless source contains unsupported construct %q", construct)
+		}
+	}
+	for _, r := range painlessSortKeySource {
+		if r > unicode.MaxASCII {
+			t.Errorf("Painless source contains non-ASCII character %q", r)
+		}
+	}
+}
+
+// TestElasticsearchValidation tests error reporting for invalid arguments
+func TestElasticsearchValidation(t *testing.T) {
+	var validationErr *ValidationError
+	if _, err := GenerateElasticsearchSortKey("", "key"); !errors.As(err, &validationErr) || validationErr.Field != "sourceField" {
+		t.Errorf("Empty source field: error = %v", err)
+	}
+	if _, err := GenerateElasticsearchSortKey("name", ""); !errors.As(err, &validationErr) || validationErr.Field != "keyField" {
+		t.Errorf("Empty key field: error = %v", err)
+	}
+	if _, err := GenerateElasticsearchSortKey("name", "key", WithMaxNumericLength(0)); !errors.As(err, &validationErr) {
+		t.Errorf("Invalid config: error = %v", err)
+	}
+	if _, err := VerifyPainlessConformance(nil); !errors.As(err, &validationErr) {
+		t.Errorf("Nil corpus: error = %v", err)
+	}
+	if _, err := VerifyPainlessConformance([]string{"ok", "bad\xff"}); !errors.As(err, &validationErr) {
+		t.Errorf("Invalid UTF-8: error = %v", err)
+	}
+}
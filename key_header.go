@@ -0,0 +1,267 @@
+package ansort
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrNoSortKeyHeader is returned when a sort key does not start with a header
+var ErrNoSortKeyHeader = errors.New("sort key has no header")
+
+// Key header layout
+//
+// A version 1 header is keyHeaderPrefix followed by the version, ':', eight
+// fixed-width fields and keyHeaderTerminator, e.g. "nsk1:p10s-0-a;":
+//
+//   - key scheme: 'p' (KeySchemePadded), 'o' (KeySchemeOrdered) or
+//     'c' (KeySchemeCompact)
+//   - MaxNumericLength as two digits, "00" unless KeySchemePadded
+//   - case: 's' (sensitive) or 'i' (insensitive)
+//   - decimal separator, or '-' when decimal numbers are disabled
+//   - SignMode as a single digit
+//   - trailing section: 'l' (lossless or case tie-break) or '-'
+//   - direction: 'a' (ascending) or 'd' (descending)
+//
+// Keys bounded by MaxKeyBytes add '/' and the bound before the terminator,
+// e.g. "nsk1:p10s-0-a/1024;".
+const (
+	keyHeaderPrefix     = "nsk"
+	keyHeaderVersion    = 1
+	keyHeaderTerminator = ';'
+	keyHeaderLength     = len("nsk1:p10s-0-a;")
+	keyHeaderBound      = '/'
+)
+
+// keySchemeHeaderCodes maps each key scheme to its header character
+var keySchemeHeaderCodes = map[KeyScheme]byte{
+	KeySchemePadded:  'p',
+	KeySchemeOrdered: 'o',
+	KeySchemeCompact: 'c',
+}
+
+// SortKeyHeader describes the configuration recorded in a key header
+type SortKeyHeader struct {
+	// Version is the header format version
+	Version int
+	// Config is the configuration that generated the key. Settings that do
+	// not affect keys are reported with their defaults.
+	Config ExternalSortKeyConfig
+}
+
+// WithKeyHeader prefixes every external sort key with a short header that
+// records the key scheme and its parameters, such as "nsk1:p10s-0-a;". Keys
+// generated with the same settings share the same header and keep their
+// order; keys from different settings never interleave, and
+// ParseSortKeyHeader reveals which settings produced a stored key.
+// Applies to string keys; binary keys are unaffected.
+//
+// Example:
+//
+//	sortKey := ansort.ToNaturalSortKey("file10", ansort.WithKeyHeader())
+//	// Result: "nsk1:p10s-0-a;file0000000010"
+func WithKeyHeader() ExternalSortKeyOption {
+	return func(c *ExternalSortKeyConfig) {
+		c.Header = true
+	}
+}
+
+// ParseSortKeyHeader parses the header of a key generated with WithKeyHeader
+// and returns it together with the rest of the key.
+//
+// Returns ErrNoSortKeyHeader if the key does not start with a header, and
+// ErrInvalidSortKey if the header is malformed or from an unsupported version.
+// A key without a header is only mistaken for one if its input starts with
+// header-like text.
+//
+// Example:
+//
+//	header, body, err := ansort.ParseSortKeyHeader(storedKey)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if !ansort.CompatibleSortKeyConfigs(header.Config, currentConfig) {
+//		// storedKey was generated with different settings and must be rebuilt
+//	}
+func ParseSortKeyHeader(key string) (SortKeyHeader, string, error) {
+	if !strings.HasPrefix(key, keyHeaderPrefix) {
+		return SortKeyHeader{}, key, ErrNoSortKeyHeader
+	}
+
+	end := strings.IndexByte(key, keyHeaderTerminator)
+	colon := strings.IndexByte(key, ':')
+	if end < 0 || colon < 0 || colon > end {
+		return SortKeyHeader{}, key, ErrNoSortKeyHeader
+	}
+	versionText := key[len(keyHeaderPrefix):colon]
+	version, err := strconv.Atoi(versionText)
+	if err != nil {
+		return SortKeyHeader{}, key, ErrNoSortKeyHeader
+	}
+	if version != keyHeaderVersion || versionText != strconv.Itoa(version) || end+1 < keyHeaderLength {
+		return SortKeyHeader{}, key, ErrInvalidSortKey
+	}
+
+	config, ok := parseKeyHeaderFields(key[colon+1 : end])
+	if !ok {
+		return SortKeyHeader{}, key, ErrInvalidSortKey
+	}
+	return SortKeyHeader{Version: version, Config: config}, key[end+1:], nil
+}
+
+// CompatibleSortKeyConfigs reports whether two configurations generate keys
+// that interleave correctly, so keys from both can be stored and sorted
+// together. Settings that do not change keys are ignored, such as
+// MaxNumericLength for KeySchemeOrdered and KeySchemeCompact.
+//
+// Example:
+//
+//	old := ansort.DefaultExternalSortKeyConfig()
+//	updated := old
+//	updated.MaxNumericLength = 20
+//	ansort.CompatibleSortKeyConfigs(old, updated) // false: keys must be rebuilt
+func CompatibleSortKeyConfigs(a, b ExternalSortKeyConfig) bool {
+	return a.keyFormat() == b.keyFormat()
+}
+
+// keyFormat returns the configuration reduced to the settings that affect
+// generated string keys, so that equivalent configurations compare equal
+func (c ExternalSortKeyConfig) keyFormat() ExternalSortKeyConfig {
+	format := ExternalSortKeyConfig{
+		CaseSensitive: c.CaseSensitive,
+		SignMode:      c.SignMode,
+		KeyScheme:     c.keyScheme(),
+		Lossless:      c.Lossless || (c.CaseTieBreak && !c.CaseSensitive),
+		Descending:    c.Descending,
+		Header:        c.Header,
+		MaxKeyBytes:   c.MaxKeyBytes,
+	}
+	if format.KeyScheme == KeySchemePadded {
+		format.MaxNumericLength = c.MaxNumericLength
+	}
+	if c.DecimalNumbers {
+		format.DecimalNumbers = true
+		format.DecimalSeparator = c.DecimalSeparator
+	}
+	return format
+}
+
+// keyHeader returns the header for keys generated with a valid configuration
+func keyHeader(config ExternalSortKeyConfig) string {
+	format := config.keyFormat()
+
+	var result strings.Builder
+	result.Grow(keyHeaderLength)
+	result.WriteString(keyHeaderPrefix)
+	result.WriteString(strconv.Itoa(keyHeaderVersion))
+	result.WriteByte(':')
+	result.WriteByte(keySchemeHeaderCodes[format.KeyScheme])
+	result.WriteByte(byte('0' + format.MaxNumericLength/10))
+	result.WriteByte(byte('0' + format.MaxNumericLength%10))
+	result.WriteByte(headerFlag(format.CaseSensitive, 's', 'i'))
+	if format.DecimalNumbers {
+		result.WriteRune(format.DecimalSeparator)
+	} else {
+		result.WriteByte('-')
+	}
+	result.WriteByte(byte('0' + format.SignMode))
+	result.WriteByte(headerFlag(format.Lossless, 'l', '-'))
+	result.WriteByte(headerFlag(format.Descending, 'd', 'a'))
+	if format.MaxKeyBytes > 0 {
+		result.WriteByte(keyHeaderBound)
+		result.WriteString(strconv.Itoa(format.MaxKeyBytes))
+	}
+	result.WriteByte(keyHeaderTerminator)
+	return result.String()
+}
+
+// headerFlag returns set when the flag is true and unset otherwise
+func headerFlag(flag bool, set, unset byte) byte {
+	if flag {
+		return set
+	}
+	return unset
+}
+
+// parseKeyHeaderFields parses the fixed-width fields of a version 1 header
+func parseKeyHeaderFields(fields string) (ExternalSortKeyConfig, bool) {
+	config := DefaultExternalSortKeyConfig()
+	config.Header = true
+	if len(fields) > 8 {
+		if fields[8] != keyHeaderBound {
+			return config, false
+		}
+		bound, err := strconv.Atoi(fields[9:])
+		if err != nil || bound <= 0 || strconv.Itoa(bound) != fields[9:] {
+			return config, false
+		}
+		config.MaxKeyBytes = bound
+		fields = fields[:8]
+	}
+	if len(fields) != 8 {
+		return config, false
+	}
+
+	switch fields[0] {
+	case 'p':
+		config.KeyScheme = KeySchemePadded
+		length, err := strconv.Atoi(fields[1:3])
+		if err != nil || length < 1 || length > 50 {
+			return config, false
+		}
+		config.MaxNumericLength = length
+	case 'o':
+		config.KeyScheme = KeySchemeOrdered
+		if fields[1:3] != "00" {
+			return config, false
+		}
+	case 'c':
+		config.KeyScheme = KeySchemeCompact
+		if fields[1:3] != "00" {
+			return config, false
+		}
+	default:
+		return config, false
+	}
+
+	switch fields[3] {
+	case 's':
+		config.CaseSensitive = true
+	case 'i':
+		config.CaseSensitive = false
+	default:
+		return config, false
+	}
+
+	switch fields[4] {
+	case '.', ',':
+		config.DecimalNumbers = true
+		config.DecimalSeparator = rune(fields[4])
+	case '-':
+	default:
+		return config, false
+	}
+
+	config.SignMode = SignMode(fields[5] - '0')
+	if validateSignMode(config.SignMode) != nil {
+		return config, false
+	}
+
+	switch fields[6] {
+	case 'l':
+		config.Lossless = true
+	case '-':
+	default:
+		return config, false
+	}
+
+	switch fields[7] {
+	case 'd':
+		config.Descending = true
+	case 'a':
+	default:
+		return config, false
+	}
+
+	return config, true
+}
@@ -0,0 +1,132 @@
+package ansort
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// TestKeyHeader tests header generation and parsing for different configurations
+func TestKeyHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []ExternalSortKeyOption
+		expected string
+	}{
+		{"default", nil, "nsk1:p10s-0-a;"},
+		{"padded length", []ExternalSortKeyOption{WithMaxNumericLength(5)}, "nsk1:p05s-0-a;"},
+		{"ordered ignores length", []ExternalSortKeyOption{WithKeyScheme(KeySchemeOrdered), WithMaxNumericLength(5)}, "nsk1:o00s-0-a;"},
+		{"case insensitive tie-break", []ExternalSortKeyOption{WithExternalCaseInsensitive(), WithExternalCaseTieBreak()}, "nsk1:o00i-0la;"},
+		{"numbers", []ExternalSortKeyOption{WithExternalDecimalNumbers(','), WithExternalSignedNumbers(SignAlways)}, "nsk1:p10s,2-a;"},
+		{"descending lossless", []ExternalSortKeyOption{WithDescendingKey(), WithLosslessKey()}, "nsk1:o00s-0ld;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]ExternalSortKeyOption{WithKeyHeader()}, tt.options...)
+			key := ToNaturalSortKey("File10", options...)
+			if !strings.HasPrefix(key, tt.expected) {
+				t.Fatalf("Key %q does not start with header %q", key, tt.expected)
+			}
+
+			header, body, err := ParseSortKeyHeader(key)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if header.Version != 1 {
+				t.Errorf("Version = %d, want 1", header.Version)
+			}
+			if body != ToNaturalSortKey("File10", tt.options...) {
+				t.Errorf("Body = %q, want key without header", body)
+			}
+			if !CompatibleSortKeyConfigs(header.Config, buildExternalSortKeyConfig(options...)) {
+				t.Errorf("Parsed config %+v is not compatible with the generating config", header.Config)
+			}
+		})
+	}
+}
+
+// TestKeyHeaderPreservesOrder verifies that keys with the same header keep their order
+func TestKeyHeaderPreservesOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(12))
+	options := []ExternalSortKeyOption{WithKeyHeader(), WithKeyScheme(KeySchemeOrdered), WithDescendingKey()}
+	for i := 0; i < 2000; i++ {
+		a, b := randomKeyTestString(rng), randomKeyTestString(rng)
+		expected := -CompareLegacy(a, b)
+		got := strings.Compare(ToNaturalSortKey(a, options...), ToNaturalSortKey(b, options...))
+		if got != expected {
+			t.Fatalf("Header key order %d, want %d for %q vs %q", got, expected, a, b)
+		}
+	}
+
+	key := ToNaturalSortKey("File007", WithKeyHeader(), WithLosslessKey(), WithDescendingKey())
+	original, err := FromNaturalSortKey(key)
+	if err != nil || original != "File007" {
+		t.Errorf("FromNaturalSortKey(%q) = %q, %v; want File007", key, original, err)
+	}
+}
+
+// TestParseSortKeyHeaderErrors tests keys without headers and malformed headers
+func TestParseSortKeyHeaderErrors(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected error
+	}{
+		{"file0000000010", ErrNoSortKeyHeader},
+		{"", ErrNoSortKeyHeader},
+		{"nsk1:p10s-0-a", ErrNoSortKeyHeader},
+		{"nskx:p10s-0-a;", ErrNoSortKeyHeader},
+		{"nsk2:p10s-0-a;", ErrInvalidSortKey},
+		{"nsk1:x10s-0-a;", ErrInvalidSortKey},
+		{"nsk1:p99s-0-a;", ErrInvalidSortKey},
+		{"nsk1:o10s-0-a;", ErrInvalidSortKey},
+		{"nsk1:p10s-9-a;", ErrInvalidSortKey},
+		{"nsk01:p10s-0-a;", ErrInvalidSortKey},
+	}
+
+	for _, tt := range tests {
+		_, body, err := ParseSortKeyHeader(tt.key)
+		if err != tt.expected {
+			t.Errorf("ParseSortKeyHeader(%q) error = %v, want %v", tt.key, err, tt.expected)
+		}
+		if body != tt.key {
+			t.Errorf("ParseSortKeyHeader(%q) body = %q, want the unchanged key", tt.key, body)
+		}
+	}
+}
+
+// TestCompatibleSortKeyConfigs tests detection of configurations with compatible keys
+func TestCompatibleSortKeyConfigs(t *testing.T) {
+	base := DefaultExternalSortKeyConfig()
+
+	tests := []struct {
+		name       string
+		modify     func(c *ExternalSortKeyConfig)
+		compatible bool
+	}{
+		{"identical", func(c *ExternalSortKeyConfig) {}, true},
+		{"padding length", func(c *ExternalSortKeyConfig) { c.MaxNumericLength = 20 }, false},
+		{"case", func(c *ExternalSortKeyConfig) { c.CaseSensitive = false }, false},
+		{"scheme", func(c *ExternalSortKeyConfig) { c.KeyScheme = KeySchemeOrdered }, false},
+		{"header", func(c *ExternalSortKeyConfig) { c.Header = true }, false},
+		{"separator without decimals", func(c *ExternalSortKeyConfig) { c.DecimalSeparator = ',' }, true},
+		{"tie-break when case-sensitive", func(c *ExternalSortKeyConfig) { c.CaseTieBreak = true }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := base
+			tt.modify(&other)
+			if got := CompatibleSortKeyConfigs(base, other); got != tt.compatible {
+				t.Errorf("CompatibleSortKeyConfigs() = %v, want %v", got, tt.compatible)
+			}
+		})
+	}
+
+	// Ordered keys do not depend on the padding length
+	a := buildExternalSortKeyConfig(WithKeyScheme(KeySchemeOrdered), WithMaxNumericLength(5))
+	b := buildExternalSortKeyConfig(WithKeyScheme(KeySchemeOrdered), WithMaxNumericLength(30))
+	if !CompatibleSortKeyConfigs(a, b) {
+		t.Error("Ordered configurations with different padding lengths should be compatible")
+	}
+}
@@ -0,0 +1,134 @@
+package ansort
+
+import (
+	"strings"
+)
+
+// losslessSeparator separates the natural-order part of a lossless key from
+// the trailing section. It sorts below every byte that can continue a
+// KeySchemeOrdered key, and never appears unescaped in the trailing section.
+const losslessSeparator = ' '
+
+// keyScheme returns the scheme keys are generated with. Keys with a trailing
+// lossless section always use KeySchemeOrdered: padded and compact keys copy
+// spaces and control characters unescaped, which would tie with or sort
+// below losslessSeparator.
+func (c ExternalSortKeyConfig) keyScheme() KeyScheme {
+	if c.Lossless || (c.CaseTieBreak && !c.CaseSensitive) {
+		return KeySchemeOrdered
+	}
+	return c.KeyScheme
+}
+
+// WithLosslessKey enables lossless external sort keys. The key is followed by
+// a trailing section that records the original input, including the casing and
+// leading zeros that padding and lower-casing discard, so FromNaturalSortKey can
+// recover the exact input.
+//
+// The trailing section only takes effect between inputs whose keys would
+// otherwise be identical, ordering them by their original text. Lossless keys
+// are always generated with KeySchemeOrdered, whatever WithKeyScheme selects,
+// so they still sort exactly like Compare.
+//
+// Example:
+//
+//	sortKey := ansort.ToNaturalSortKey("File007", ansort.WithExternalCaseInsensitive(),
+//		ansort.WithLosslessKey())
+//	// Result: "2file!\"111713 File007!\""
+//	original, _ := ansort.FromNaturalSortKey(sortKey)
+//	// original: "File007"
+func WithLosslessKey() ExternalSortKeyOption {
+	return func(c *ExternalSortKeyConfig) {
+		c.Lossless = true
+	}
+}
+
+// FromNaturalSortKey recovers the original input from an external sort key
+// generated with WithLosslessKey. Descending keys and keys with a header are
+// detected and decoded too.
+//
+// Returns ErrInvalidSortKey if the key has no valid lossless section.
+//
+// Example:
+//
+//	sortKey := ansort.ToNaturalSortKey("File10.txt", ansort.WithLosslessKey())
+//	original, err := ansort.FromNaturalSortKey(sortKey)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	// original: "File10.txt"
+func FromNaturalSortKey(key string) (string, error) {
+	if _, body, err := ParseSortKeyHeader(key); err == nil {
+		key = body
+	}
+	if key == "" {
+		return "", nil
+	}
+
+	// Ascending lossless keys always end with orderedTerminator, so a trailing
+	// descendingTerminator identifies a descending key
+	if strings.HasSuffix(key, string(descendingTerminator)) {
+		ascending, err := ascendingKey(key)
+		if err != nil {
+			return "", err
+		}
+		if ascending == "" {
+			return "", nil
+		}
+		key = ascending
+	}
+
+	// The trailing section never contains an unescaped separator
+	idx := strings.LastIndexByte(key, losslessSeparator)
+	if idx < 0 {
+		return "", ErrInvalidSortKey
+	}
+	return decodeOrderedText(key[idx+1:])
+}
+
+// appendLosslessSection appends the separator and the escaped original input
+func appendLosslessSection(key string, input string) string {
+	var result strings.Builder
+	result.Grow(len(key) + len(input) + 3)
+	result.WriteString(key)
+	result.WriteByte(losslessSeparator)
+	writeOrderedText(&result, input)
+	return result.String()
+}
+
+// decodeOrderedText decodes text written by writeOrderedText. The text must
+// end with exactly one orderedTerminator.
+func decodeOrderedText(encoded string) (string, error) {
+	var result strings.Builder
+	result.Grow(len(encoded))
+
+	for i := 0; i < len(encoded); i++ {
+		c := encoded[i]
+		if c != orderedEscape {
+			if c < orderedEscape {
+				return "", ErrInvalidSortKey
+			}
+			result.WriteByte(c)
+			continue
+		}
+
+		if i+1 >= len(encoded) {
+			return "", ErrInvalidSortKey
+		}
+		i++
+		next := encoded[i]
+		if next == orderedTerminator[1] {
+			// The terminator must end the section
+			if i != len(encoded)-1 {
+				return "", ErrInvalidSortKey
+			}
+			return result.String(), nil
+		}
+		if next < orderedEscapeShift || next > orderedEscape+orderedEscapeShift {
+			return "", ErrInvalidSortKey
+		}
+		result.WriteByte(next - orderedEscapeShift)
+	}
+
+	return "", ErrInvalidSortKey
+}
@@ -0,0 +1,96 @@
+package ansort
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// TestLosslessKeyRoundTrip verifies that lossless keys decode to the original input
+func TestLosslessKeyRoundTrip(t *testing.T) {
+	key := ToNaturalSortKey("File007", WithExternalCaseInsensitive(), WithLosslessKey())
+	if expected := "2file!\"111713 File007!\""; key != expected {
+		t.Errorf("ToNaturalSortKey() = %q, want %q", key, expected)
+	}
+
+	configs := map[string][]ExternalSortKeyOption{
+		"padded":                   {WithLosslessKey()},
+		"padded case insensitive":  {WithLosslessKey(), WithExternalCaseInsensitive()},
+		"ordered":                  {WithLosslessKey(), WithKeyScheme(KeySchemeOrdered)},
+		"ordered case insensitive": {WithLosslessKey(), WithKeyScheme(KeySchemeOrdered), WithExternalCaseInsensitive()},
+	}
+
+	rng := rand.New(rand.NewSource(11))
+	for name, options := range configs {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 2000; i++ {
+				input := randomKeyTestString(rng)
+				decoded, err := FromNaturalSortKey(ToNaturalSortKey(input, options...))
+				if err != nil || decoded != input {
+					t.Fatalf("FromNaturalSortKey(ToNaturalSortKey(%q)) = %q, %v", input, decoded, err)
+				}
+			}
+		})
+	}
+}
+
+// TestLosslessKeyOrdering verifies that lossless keys keep natural order
+// whatever scheme is requested
+func TestLosslessKeyOrdering(t *testing.T) {
+	compareOptions := []Option{WithCaseInsensitive()}
+	for _, scheme := range []KeyScheme{KeySchemePadded, KeySchemeOrdered, KeySchemeCompact} {
+		options := []ExternalSortKeyOption{WithLosslessKey(), WithKeyScheme(scheme), WithExternalCaseInsensitive()}
+
+		rng := rand.New(rand.NewSource(13))
+		for i := 0; i < 5000; i++ {
+			a := randomKeyTestString(rng)
+			b := randomKeyTestString(rng)
+
+			expected := Compare(a, b, compareOptions...)
+			got := strings.Compare(ToNaturalSortKey(a, options...), ToNaturalSortKey(b, options...))
+			if expected != 0 && got != expected {
+				t.Fatalf("Scheme %d: key order %d differs from Compare %d for %q vs %q", scheme, got, expected, a, b)
+			}
+			if (got == 0) != (a == b) {
+				t.Fatalf("Scheme %d: lossless keys for %q and %q should only be equal for equal inputs", scheme, a, b)
+			}
+		}
+	}
+}
+
+// TestLosslessKeySeparator tests that spaces and control characters in the
+// input do not sort below the lossless separator
+func TestLosslessKeySeparator(t *testing.T) {
+	pairs := [][2]string{{"b", "b a"}, {"~", "~\t9-"}, {"a", "a\x01"}, {"x 1", "x\t1"}}
+	for _, scheme := range []KeyScheme{KeySchemePadded, KeySchemeOrdered, KeySchemeCompact} {
+		options := []ExternalSortKeyOption{WithLosslessKey(), WithKeyScheme(scheme)}
+		for _, pair := range pairs {
+			expected := Compare(pair[0], pair[1])
+			got := strings.Compare(ToNaturalSortKey(pair[0], options...), ToNaturalSortKey(pair[1], options...))
+			if got != expected {
+				t.Errorf("Scheme %d: key order %d differs from Compare %d for %q vs %q", scheme, got, expected, pair[0], pair[1])
+			}
+		}
+	}
+}
+
+// TestFromNaturalSortKeyErrors tests decoding of keys without a valid lossless section
+func TestFromNaturalSortKeyErrors(t *testing.T) {
+	invalid := []string{
+		"file0000000010",             // Not generated in lossless mode
+		"file0000000010 file10",      // Missing terminator
+		"file0000000010 file10!\"xx", // Data after terminator
+		"file0000000010 file!z10!\"", // Invalid escape
+	}
+
+	for _, key := range invalid {
+		if _, err := FromNaturalSortKey(key); !errors.Is(err, ErrInvalidSortKey) {
+			t.Errorf("FromNaturalSortKey(%q) error = %v, want ErrInvalidSortKey", key, err)
+		}
+	}
+
+	if decoded, err := FromNaturalSortKey(""); err != nil || decoded != "" {
+		t.Errorf("FromNaturalSortKey(\"\") = %q, %v; want empty string", decoded, err)
+	}
+}
@@ -0,0 +1,222 @@
+package ansort
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrMetricsPublished is returned when publishing metrics under an expvar
+// name that is already in use
+var ErrMetricsPublished = errors.New("expvar name is already published")
+
+// collatorMetrics holds the monotonic counters of a Collator
+type collatorMetrics struct {
+	hits          atomic.Int64
+	misses        atomic.Int64
+	stringsParsed atomic.Int64
+	tokensParsed  atomic.Int64
+	// retiredEvictions counts evictions from caches the collator replaced
+	retiredEvictions atomic.Int64
+	sorts            [sortStrategyCount]atomic.Int64
+}
+
+// MetricsSnapshot is a point-in-time copy of a Collator's metrics. Counters
+// only increase over the collator's lifetime, so monitoring systems can
+// compute rates from them; ResetStats does not reset them.
+type MetricsSnapshot struct {
+	// Hits is the number of tokenizations served from the cache
+	Hits int64 `json:"hits"`
+	// Misses is the number of cache lookups that had to parse the string
+	Misses int64 `json:"misses"`
+	// Evictions is the number of entries evicted from the collator's caches
+	Evictions int64 `json:"evictions"`
+	// StringsParsed is the number of strings tokenized
+	StringsParsed int64 `json:"strings_parsed"`
+	// TokensParsed is the number of tokens produced by tokenizing
+	TokensParsed int64 `json:"tokens_parsed"`
+	// CacheEntries is the current number of cached tokenizations
+	CacheEntries int `json:"cache_entries"`
+	// CacheBytes is the memory currently held by the cache
+	CacheBytes int64 `json:"cache_bytes"`
+	// Sorts counts sorts by the strategy used for them, keyed by strategy
+	// name such as "cached" or "legacy"
+	Sorts map[string]int64 `json:"sorts"`
+}
+
+// Metrics returns a snapshot of the collator's metrics
+func (c *Collator) Metrics() MetricsSnapshot {
+	cache := c.cache.Load()
+	m := MetricsSnapshot{
+		Hits:          c.metrics.hits.Load(),
+		Misses:        c.metrics.misses.Load(),
+		Evictions:     c.metrics.retiredEvictions.Load() + cache.Evictions(),
+		StringsParsed: c.metrics.stringsParsed.Load(),
+		TokensParsed:  c.metrics.tokensParsed.Load(),
+		CacheEntries:  cache.Size(),
+		CacheBytes:    cache.Bytes(),
+		Sorts:         make(map[string]int64, sortStrategyCount-1),
+	}
+	for strategy := StrategyLegacy; strategy < sortStrategyCount; strategy++ {
+		m.Sorts[strategy.String()] = c.metrics.sorts[strategy].Load()
+	}
+	return m
+}
+
+// GlobalMetrics returns a snapshot of the metrics of the package-level functions
+func GlobalMetrics() MetricsSnapshot {
+	return defaultCollator.Metrics()
+}
+
+// publishMu serializes PublishExpvar, since expvar.Publish panics on
+// duplicate names
+var publishMu sync.Mutex
+
+// PublishExpvar publishes the collator's metrics as an expvar variable,
+// served as JSON on /debug/vars by the expvar package's handler. Each read
+// takes a fresh snapshot.
+//
+// Returns ErrMetricsPublished if the name is already in use.
+//
+// Example:
+//
+//	if err := collator.PublishExpvar("ansort_tenant_a"); err != nil {
+//		log.Fatal(err)
+//	}
+func (c *Collator) PublishExpvar(name string) error {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("%w: %s", ErrMetricsPublished, name)
+	}
+	expvar.Publish(name, expvar.Func(func() any {
+		return c.Metrics()
+	}))
+	return nil
+}
+
+// PublishExpvar publishes the metrics of the package-level functions as an
+// expvar variable
+//
+// Example:
+//
+//	import _ "expvar" // Registers /debug/vars
+//
+//	if err := ansort.PublishExpvar("ansort"); err != nil {
+//		log.Fatal(err)
+//	}
+func PublishExpvar(name string) error {
+	return defaultCollator.PublishExpvar(name)
+}
+
+// metricFamily is a metric with its samples, in exposition order
+type metricFamily struct {
+	name    string
+	help    string
+	counter bool
+	samples []metricSample
+}
+
+// metricSample is one value of a metric family
+type metricSample struct {
+	labels string
+	value  int64
+}
+
+// families returns the snapshot as metric families
+func (m MetricsSnapshot) families() []metricFamily {
+	sorts := make([]metricSample, 0, len(m.Sorts))
+	for name, count := range m.Sorts {
+		sorts = append(sorts, metricSample{labels: `{path="` + name + `"}`, value: count})
+	}
+	sort.Slice(sorts, func(i, j int) bool { return sorts[i].labels < sorts[j].labels })
+
+	return []metricFamily{
+		{"ansort_cache_hits", "Tokenizations served from the cache.", true, []metricSample{{value: m.Hits}}},
+		{"ansort_cache_misses", "Cache lookups that had to parse the string.", true, []metricSample{{value: m.Misses}}},
+		{"ansort_cache_evictions", "Entries evicted from the cache to make room.", true, []metricSample{{value: m.Evictions}}},
+		{"ansort_strings_parsed", "Strings tokenized.", true, []metricSample{{value: m.StringsParsed}}},
+		{"ansort_tokens_parsed", "Tokens produced by tokenizing.", true, []metricSample{{value: m.TokensParsed}}},
+		{"ansort_cache_entries", "Tokenizations currently cached.", false, []metricSample{{value: int64(m.CacheEntries)}}},
+		{"ansort_cache_bytes", "Memory held by cached tokenizations, in bytes.", false, []metricSample{{value: m.CacheBytes}}},
+		{"ansort_sorts", "Sorts by the strategy used for them.", true, sorts},
+	}
+}
+
+// WritePrometheus writes the snapshot in the Prometheus text exposition
+// format (version 0.0.4)
+func (m MetricsSnapshot) WritePrometheus(w io.Writer) error {
+	return writeMetrics(w, m.families(), false)
+}
+
+// WriteOpenMetrics writes the snapshot in the OpenMetrics text format
+func (m MetricsSnapshot) WriteOpenMetrics(w io.Writer) error {
+	return writeMetrics(w, m.families(), true)
+}
+
+// writeMetrics writes metric families in the Prometheus or OpenMetrics
+// text format. The formats differ in how counters are named in TYPE lines
+// and in the closing EOF marker.
+func writeMetrics(w io.Writer, families []metricFamily, openMetrics bool) error {
+	var b strings.Builder
+	for _, family := range families {
+		sampleName, typeName, kind := family.name, family.name, "gauge"
+		if family.counter {
+			sampleName, kind = family.name+"_total", "counter"
+			if !openMetrics {
+				typeName = sampleName
+			}
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", typeName, family.help, typeName, kind)
+		for _, sample := range family.samples {
+			fmt.Fprintf(&b, "%s%s %d\n", sampleName, sample.labels, sample.value)
+		}
+	}
+	if openMetrics {
+		b.WriteString("# EOF\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Content types of the metrics formats
+const (
+	prometheusContentType  = "text/plain; version=0.0.4; charset=utf-8"
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// MetricsHandler returns an HTTP handler serving the collator's metrics for
+// scraping. It serves OpenMetrics to clients that accept it and the
+// Prometheus text format otherwise.
+//
+// Example:
+//
+//	http.Handle("/metrics/ansort", collator.MetricsHandler())
+func (c *Collator) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snapshot := c.Metrics()
+		if strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text") {
+			w.Header().Set("Content-Type", openMetricsContentType)
+			snapshot.WriteOpenMetrics(w)
+			return
+		}
+		w.Header().Set("Content-Type", prometheusContentType)
+		snapshot.WritePrometheus(w)
+	})
+}
+
+// MetricsHandler returns an HTTP handler serving the metrics of the
+// package-level functions
+//
+// Example:
+//
+//	http.Handle("/metrics/ansort", ansort.MetricsHandler())
+func MetricsHandler() http.Handler {
+	return defaultCollator.MetricsHandler()
+}
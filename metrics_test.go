@@ -0,0 +1,202 @@
+package ansort
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestCollatorMetricsCounters tests hit, miss and parse counting
+func TestCollatorMetricsCounters(t *testing.T) {
+	c, _ := NewCollator()
+	long1, long2 := "metrics_long_string_1", "metrics_long_string_2"
+	c.Compare(long1, long2)
+	c.Compare(long1, long2)
+	c.Compare("a1", "a2") // Short strings bypass the cache
+
+	m := c.Metrics()
+	tokens := int64(len(parseString(long1)) + len(parseString(long2)) + len(parseString("a1")) + len(parseString("a2")))
+	if m.Hits != 2 || m.Misses != 2 || m.StringsParsed != 4 || m.TokensParsed != tokens {
+		t.Errorf("Metrics = %+v, want 2 hits, 2 misses, 4 strings and %d tokens parsed", m, tokens)
+	}
+	if m.CacheEntries != 2 || m.CacheBytes != c.Stats().CacheBytes || m.CacheBytes == 0 {
+		t.Errorf("Metrics = %+v, want 2 cached entries", m)
+	}
+
+	// ResetStats resets Stats but not the monotonic metrics
+	c.ResetStats()
+	c.Compare(long1, long2)
+	if stats := c.Stats(); stats.Hits != 2 || stats.Misses != 0 {
+		t.Errorf("Stats after reset = %+v, want 2 hits", stats)
+	}
+	if m := c.Metrics(); m.Hits != 4 || m.Misses != 2 {
+		t.Errorf("Metrics after reset = %+v, want 4 hits and 2 misses", m)
+	}
+}
+
+// TestCollatorMetricsSortPaths tests that sorts record the path shouldUseCaching chose
+func TestCollatorMetricsSortPaths(t *testing.T) {
+	c, _ := NewCollator()
+	small := []string{"b2", "a10", "a1"}
+	large := make([]string, 400)
+	for i := range large {
+		large[i] = fmt.Sprintf("item%d", 400-i)
+	}
+
+	c.Sort(small)
+	c.Sort(large)
+	c.DisableCache()
+	c.Sort(large)
+
+	if sorts := c.Metrics().Sorts; sorts["legacy"] != 2 || sorts["cached"] != 1 {
+		t.Errorf("Sorts = %v, want 2 legacy and 1 cached", sorts)
+	}
+}
+
+// TestCollatorMetricsEvictions tests that evictions survive cache replacement
+func TestCollatorMetricsEvictions(t *testing.T) {
+	c, _ := NewCollator(WithCollatorCacheSize(2))
+	for i := 0; i < 3; i++ {
+		c.Compare(fmt.Sprintf("evicted_string_%d", 2*i), fmt.Sprintf("evicted_string_%d", 2*i+1))
+	}
+	if m := c.Metrics(); m.Evictions != 4 {
+		t.Errorf("Evictions = %d, want 4", m.Evictions)
+	}
+
+	c.ConfigureCacheSize(10)
+	c.Compare("evicted_string_0", "evicted_string_1")
+	if m := c.Metrics(); m.Evictions != 4 || m.CacheEntries != 2 {
+		t.Errorf("Metrics after ConfigureCacheSize = %+v, want 4 evictions", m)
+	}
+}
+
+// TestCollatorMetricsConcurrentUse tests that no counts are lost under
+// concurrent use (run with -race)
+func TestCollatorMetricsConcurrentUse(t *testing.T) {
+	c, _ := NewCollator()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				c.Compare(fmt.Sprintf("concurrent_metric_%d", i), "concurrent_metric_x")
+				c.Metrics()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if m := c.Metrics(); m.Hits+m.Misses != 1600 || m.Misses != m.StringsParsed {
+		t.Errorf("Metrics = %+v, want 1600 lookups", m)
+	}
+}
+
+// metricLine matches a sample line of the text exposition formats
+var metricLine = regexp.MustCompile(`^[a-z_]+(\{[a-z_]+="[a-z_-]+"\})? \d+$`)
+
+// TestMetricsHandler tests scraping metrics in both text formats
+func TestMetricsHandler(t *testing.T) {
+	c, _ := NewCollator()
+	c.Compare("scraped_long_string_1", "scraped_long_string_2")
+	c.Compare("scraped_long_string_1", "scraped_long_string_2")
+	c.Sort([]string{"b", "a"})
+
+	server := httptest.NewServer(c.MetricsHandler())
+	defer server.Close()
+
+	scrape := func(accept string) (string, string) {
+		request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		return response.Header.Get("Content-Type"), string(body)
+	}
+
+	contentType, body := scrape("")
+	if !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	for _, want := range []string{
+		"# TYPE ansort_cache_hits_total counter\nansort_cache_hits_total 2\n",
+		"# TYPE ansort_cache_bytes gauge\n",
+		`ansort_sorts_total{path="legacy"} 1`,
+		`ansort_sorts_total{path="cached"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Prometheus output is missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "# EOF") {
+		t.Error("Prometheus output should not end with an EOF marker")
+	}
+
+	contentType, body = scrape("application/openmetrics-text;version=1.0.0,text/plain;q=0.5")
+	if !strings.HasPrefix(contentType, "application/openmetrics-text") {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	if !strings.Contains(body, "# TYPE ansort_cache_misses counter\nansort_cache_misses_total 2\n") ||
+		!strings.HasSuffix(body, "\n# EOF\n") {
+		t.Errorf("Unexpected OpenMetrics output:\n%s", body)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(body, "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") && !metricLine.MatchString(line) {
+			t.Errorf("Malformed sample line %q", line)
+		}
+	}
+}
+
+// TestPublishExpvar tests publishing metrics on /debug/vars
+func TestPublishExpvar(t *testing.T) {
+	c, _ := NewCollator()
+	if err := c.PublishExpvar("ansort_test_metrics"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := c.PublishExpvar("ansort_test_metrics"); !errors.Is(err, ErrMetricsPublished) {
+		t.Errorf("Publishing twice: error = %v, want ErrMetricsPublished", err)
+	}
+	c.Compare("published_long_string_1", "published_long_string_2")
+
+	server := httptest.NewServer(expvar.Handler())
+	defer server.Close()
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer response.Body.Close()
+
+	var vars struct {
+		Metrics MetricsSnapshot `json:"ansort_test_metrics"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&vars); err != nil {
+		t.Fatalf("Invalid /debug/vars JSON: %v", err)
+	}
+	if vars.Metrics.Misses != 2 || vars.Metrics.CacheEntries != 2 || vars.Metrics.Sorts == nil {
+		t.Errorf("Published metrics = %+v", vars.Metrics)
+	}
+}
+
+// TestGlobalMetrics tests that the package-level functions report metrics
+func TestGlobalMetrics(t *testing.T) {
+	before := GlobalMetrics()
+	SortStringsOptimized([]string{"global_b", "global_a"})
+	after := GlobalMetrics()
+	if after.Sorts["legacy"] != before.Sorts["legacy"]+1 {
+		t.Errorf("Legacy sorts went from %d to %d", before.Sorts["legacy"], after.Sorts["legacy"])
+	}
+}
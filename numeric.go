@@ -0,0 +1,334 @@
+package ansort
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SignMode determines when a '-' or '+' directly before a number is treated
+// as the sign of that number rather than as ordinary text
+type SignMode int
+
+const (
+	// SignNone treats '-' and '+' as ordinary text (default)
+	SignNone SignMode = iota
+	// SignStandalone treats a sign as part of the number only when it is not
+	// preceded by a letter or digit, e.g. "-5" or "temp -5" but not "a-5"
+	SignStandalone
+	// SignAlways treats any '-' or '+' directly before a number as its sign,
+	// e.g. "offset-10" is read as "offset" followed by -10
+	SignAlways
+)
+
+// numberFormat describes how numeric tokens are recognized beyond plain digit runs.
+// The zero value corresponds to the default version-style tokenization.
+type numberFormat struct {
+	// decimalSeparator is the radix character joining integer and fractional
+	// digits, or 0 when decimal numbers are disabled
+	decimalSeparator rune
+	// signMode determines when a leading '-' or '+' belongs to the number
+	signMode SignMode
+}
+
+// numberFormat returns the number format selected by the configuration
+func (c Config) numberFormat() numberFormat {
+	format := numberFormat{signMode: c.SignMode}
+	if c.DecimalNumbers {
+		format.decimalSeparator = c.DecimalSeparator
+	}
+	return format
+}
+
+// numberFormat returns the number format selected by the configuration
+func (c ExternalSortKeyConfig) numberFormat() numberFormat {
+	format := numberFormat{signMode: c.SignMode}
+	if c.DecimalNumbers {
+		format.decimalSeparator = c.DecimalSeparator
+	}
+	return format
+}
+
+// validateDecimalSeparator validates the radix character for decimal numbers
+// Returns an error if the separator is not supported
+func validateDecimalSeparator(separator rune) error {
+	if separator != '.' && separator != ',' {
+		return &ValidationError{
+			Field:   "DecimalSeparator",
+			Message: "must be '.' or ','",
+		}
+	}
+	return nil
+}
+
+// validateSignMode validates the sign recognition mode
+// Returns an error if the mode is unknown
+func validateSignMode(mode SignMode) error {
+	if mode < SignNone || mode > SignAlways {
+		return &ValidationError{
+			Field:   "SignMode",
+			Message: "must be SignNone, SignStandalone or SignAlways",
+		}
+	}
+	return nil
+}
+
+// refineTokens applies the number format to a default tokenization.
+// Tokens produced by parseString are never modified; when no refinement
+// applies the input slice is returned unchanged, so cached tokenizations
+// can be shared between configurations.
+func refineTokens(tokens []Token, format numberFormat) []Token {
+	if format.decimalSeparator != 0 {
+		tokens = mergeDecimalTokens(tokens, format.decimalSeparator)
+	}
+	if format.signMode != SignNone {
+		tokens = attachSignTokens(tokens, format.signMode)
+	}
+	return tokens
+}
+
+// mergeDecimalTokens joins a numeric token, a separator token and a following
+// numeric token into a single decimal numeric token ("3", ".", "14" -> "3.14").
+// Merging is greedy from the left, so "1.2.3" becomes "1.2", ".", "3".
+func mergeDecimalTokens(tokens []Token, separator rune) []Token {
+	sep := string(separator)
+
+	// Only allocate when at least one decimal number is present
+	if !hasDecimalPattern(tokens, sep) {
+		return tokens
+	}
+
+	merged := make([]Token, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		if isDecimalPatternAt(tokens, i, sep) {
+			merged = append(merged, Token{
+				Type:  NumericToken,
+				Value: tokens[i].Value + sep + tokens[i+2].Value,
+			})
+			i += 2
+			continue
+		}
+		merged = append(merged, tokens[i])
+	}
+	return merged
+}
+
+// hasDecimalPattern reports whether any numeric-separator-numeric sequence exists
+func hasDecimalPattern(tokens []Token, sep string) bool {
+	for i := range tokens {
+		if isDecimalPatternAt(tokens, i, sep) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDecimalPatternAt reports whether tokens[i:i+3] form a decimal number
+func isDecimalPatternAt(tokens []Token, i int, sep string) bool {
+	return i+2 < len(tokens) &&
+		tokens[i].Type == NumericToken &&
+		tokens[i+1].Type == AlphaToken &&
+		tokens[i+1].Value == sep &&
+		tokens[i+2].Type == NumericToken
+}
+
+// attachSignTokens moves a trailing '-' or '+' from an alphabetic token onto
+// the numeric token that follows it ("offset-", "10" -> "offset", "-10").
+// The input slice is returned unchanged when no sign applies.
+func attachSignTokens(tokens []Token, mode SignMode) []Token {
+	var signed []Token
+
+	for i := 0; i < len(tokens); i++ {
+		if !isSignAt(tokens, i, mode) {
+			if signed != nil {
+				signed = append(signed, tokens[i])
+			}
+			continue
+		}
+
+		// Copy the tokens seen so far on the first sign found
+		if signed == nil {
+			signed = make([]Token, i, len(tokens)+1)
+			copy(signed, tokens[:i])
+		}
+
+		alpha := tokens[i].Value
+		sign := alpha[len(alpha)-1:]
+		if len(alpha) > 1 {
+			signed = append(signed, Token{Type: AlphaToken, Value: alpha[:len(alpha)-1]})
+		}
+		signed = append(signed, Token{Type: NumericToken, Value: sign + tokens[i+1].Value})
+		i++
+	}
+
+	if signed == nil {
+		return tokens
+	}
+	return signed
+}
+
+// isSignAt reports whether tokens[i] ends with a sign that belongs to tokens[i+1]
+func isSignAt(tokens []Token, i int, mode SignMode) bool {
+	if i+1 >= len(tokens) || tokens[i].Type != AlphaToken || tokens[i+1].Type != NumericToken {
+		return false
+	}
+
+	alpha := tokens[i].Value
+	last := alpha[len(alpha)-1]
+	if last != '-' && last != '+' {
+		return false
+	}
+	if mode == SignAlways {
+		return true
+	}
+
+	// SignStandalone: the sign must not follow a letter or digit
+	if len(alpha) > 1 {
+		prev, _ := utf8.DecodeLastRuneInString(alpha[:len(alpha)-1])
+		return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+	}
+	// A sign at the start of a token follows the previous numeric token, if any
+	return i == 0
+}
+
+// splitSign separates an optional leading sign from a numeric value
+func splitSign(value string) (negative bool, magnitude string) {
+	if len(value) > 0 && (value[0] == '-' || value[0] == '+') {
+		return value[0] == '-', value[1:]
+	}
+	return false, value
+}
+
+// signedMagnitude splits a numeric token into its sign and ASCII magnitude
+// according to the number format. Zero is never negative.
+func signedMagnitude(value string, format numberFormat) (negative bool, magnitude string) {
+	magnitude = normalizeDigits(value)
+	if format.signMode != SignNone {
+		negative, magnitude = splitSign(magnitude)
+	}
+	return negative && !isZeroMagnitude(magnitude, format.decimalSeparator), magnitude
+}
+
+// splitDecimal splits a decimal value into its integer and fractional digits.
+// The fractional part is empty when the value has no separator.
+func splitDecimal(value string, separator rune) (integer, fraction string) {
+	if separator == 0 {
+		return value, ""
+	}
+	if idx := strings.IndexRune(value, separator); idx >= 0 {
+		return value[:idx], value[idx+utf8.RuneLen(separator):]
+	}
+	return value, ""
+}
+
+// isZeroMagnitude reports whether an unsigned numeric value equals zero
+func isZeroMagnitude(magnitude string, separator rune) bool {
+	integer, fraction := splitDecimal(magnitude, separator)
+	return strings.TrimLeft(integer, "0") == "" && strings.TrimRight(fraction, "0") == ""
+}
+
+// compareFormattedNumbers compares two numeric values that may carry a sign
+// and a fractional part according to the number format. Negative values sort
+// below zero with larger magnitudes first, fractions compare as real values
+// ("2.5" is greater than "2.10"), and numerically equal values use the same
+// tie-break as plain integers. Negative and positive zero are equal.
+func compareFormattedNumbers(a, b string, format numberFormat) int {
+	aNorm := normalizeDigits(a)
+	bNorm := normalizeDigits(b)
+
+	aNegative, aMagnitude := splitSign(aNorm)
+	bNegative, bMagnitude := splitSign(bNorm)
+	aNegative = aNegative && !isZeroMagnitude(aMagnitude, format.decimalSeparator)
+	bNegative = bNegative && !isZeroMagnitude(bMagnitude, format.decimalSeparator)
+
+	if aNegative != bNegative {
+		if aNegative {
+			return -1
+		}
+		return 1
+	}
+
+	result := compareMagnitudes(aMagnitude, bMagnitude, format.decimalSeparator)
+	if aNegative {
+		result = -result
+	}
+	if result != 0 {
+		return result
+	}
+	return compareNumericTieBreak(a, b, aNorm, bNorm)
+}
+
+// compareMagnitudes compares two unsigned ASCII numeric values, including
+// any fractional part after the separator.
+func compareMagnitudes(a, b string, separator rune) int {
+	aInt, aFrac := splitDecimal(a, separator)
+	bInt, bFrac := splitDecimal(b, separator)
+
+	if result := compareIntegerDigits(aInt, bInt); result != 0 {
+		return result
+	}
+	return compareFractionDigits(aFrac, bFrac)
+}
+
+// compareFractionDigits compares two ASCII fractional digit strings by value.
+// Trailing zeros are insignificant, and once they are removed plain
+// lexicographic order matches numeric order.
+func compareFractionDigits(a, b string) int {
+	return strings.Compare(strings.TrimRight(a, "0"), strings.TrimRight(b, "0"))
+}
+
+// writeFormattedNumberKey writes the sort key representation of a numeric
+// value that may carry a sign and a fractional part.
+//
+// The integer part is left-padded to MaxNumericLength. With decimal numbers,
+// the fractional part is right-padded to the same width so that every value
+// occupies a fixed width. Negative values are written as '-' (which sorts
+// below every digit) followed by the nines' complement of the padded digits,
+// so larger magnitudes produce smaller keys. A negative fraction is followed
+// by ':', which sorts above every digit: fractions longer than the width are
+// not truncated, and the terminator makes a complemented fraction sort
+// after the longer complemented fractions it prefixes.
+func writeFormattedNumberKey(result *strings.Builder, value string, config ExternalSortKeyConfig) {
+	format := config.numberFormat()
+	negative, magnitude := splitSign(normalizeDigits(value))
+	negative = negative && !isZeroMagnitude(magnitude, format.decimalSeparator)
+
+	integer, fraction := splitDecimal(magnitude, format.decimalSeparator)
+	digits := padNumericToken(integer, config.MaxNumericLength)
+	if format.decimalSeparator != 0 {
+		fraction = strings.TrimRight(fraction, "0")
+		if len(fraction) < config.MaxNumericLength {
+			fraction += strings.Repeat("0", config.MaxNumericLength-len(fraction))
+		}
+	}
+
+	if negative {
+		result.WriteByte('-')
+		digits = complementDigits(digits)
+		fraction = complementDigits(fraction)
+	}
+
+	result.WriteString(digits)
+	if format.decimalSeparator != 0 {
+		result.WriteRune(format.decimalSeparator)
+		result.WriteString(fraction)
+		if negative {
+			result.WriteByte(negativeFractionTerminator)
+		}
+	}
+}
+
+// negativeFractionTerminator ends the complemented fraction of a negative
+// padded number
+const negativeFractionTerminator = ':'
+
+// complementDigits replaces every ASCII digit d with 9-d
+func complementDigits(digits string) string {
+	complemented := []byte(digits)
+	for i, c := range complemented {
+		if c >= '0' && c <= '9' {
+			complemented[i] = '9' - (c - '0')
+		}
+	}
+	return string(complemented)
+}
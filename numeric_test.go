@@ -0,0 +1,230 @@
+package ansort
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestDecimalNumbers tests direct sorting with decimal number recognition
+func TestDecimalNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		options  []Option
+		expected []string
+	}{
+		{
+			name:     "fractions compare by value",
+			input:    []string{"price2.10", "price2.5", "price10", "price2"},
+			options:  []Option{WithDecimalNumbers('.')},
+			expected: []string{"price2", "price2.10", "price2.5", "price10"},
+		},
+		{
+			name:     "comma separator",
+			input:    []string{"weight1,75kg", "weight1,8kg", "weight1,125kg"},
+			options:  []Option{WithDecimalNumbers(',')},
+			expected: []string{"weight1,125kg", "weight1,75kg", "weight1,8kg"},
+		},
+		{
+			name:     "trailing zeros tie-break shorter first",
+			input:    []string{"x2.50", "x2.5", "x2.4"},
+			options:  []Option{WithDecimalNumbers('.')},
+			expected: []string{"x2.4", "x2.5", "x2.50"},
+		},
+		{
+			name:     "default keeps version-style ordering",
+			input:    []string{"price2.10", "price2.5", "price10"},
+			options:  nil,
+			expected: []string{"price2.5", "price2.10", "price10"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, sortFn := range map[string]func([]string, ...Option){
+				"SortStrings":          SortStrings,
+				"SortStringsLegacy":    SortStringsLegacy,
+				"SortStringsPooled":    SortStringsPooled,
+				"SortStringsValidated": func(d []string, o ...Option) { _ = SortStringsValidated(d, o...) },
+				"CachedSorter":         func(d []string, o ...Option) { sort.Sort(NewCachedSorter(d, o...)) },
+			} {
+				data := make([]string, len(tt.input))
+				copy(data, tt.input)
+				sortFn(data, tt.options...)
+				if !reflect.DeepEqual(data, tt.expected) {
+					t.Errorf("%s: got %v, want %v", name, data, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+// TestDecimalNumberValidation tests validation of the decimal separator
+func TestDecimalNumberValidation(t *testing.T) {
+	if _, err := CompareValidated("a1.5", "a1.25", WithDecimalNumbers(';')); err == nil {
+		t.Error("Expected error for unsupported decimal separator")
+	}
+
+	result, err := CompareValidated("a1.5", "a1.25", WithDecimalNumbers('.'))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result != 1 {
+		t.Errorf("CompareValidated(a1.5, a1.25) = %d, want 1", result)
+	}
+
+	if _, err := ToNaturalSortKeyValidated("a1.5", WithExternalDecimalNumbers('x')); err == nil {
+		t.Error("Expected error for unsupported external decimal separator")
+	} else if valErr, ok := err.(*ValidationError); !ok || valErr.Field != "DecimalSeparator" {
+		t.Errorf("Expected DecimalSeparator ValidationError, got %v", err)
+	}
+}
+
+// TestDecimalSortKeys tests that decimal sort keys preserve decimal ordering
+func TestDecimalSortKeys(t *testing.T) {
+	key := ToNaturalSortKey("price2.5", WithMaxNumericLength(3), WithExternalDecimalNumbers('.'))
+	if expected := "price002.500"; key != expected {
+		t.Errorf("ToNaturalSortKey() = %q, want %q", key, expected)
+	}
+
+	data := []string{"price2.10", "price2.5", "price10", "price2", "price2a", "price2.05x", "price0.999"}
+	natural := make([]string, len(data))
+	copy(natural, data)
+	SortStrings(natural, WithDecimalNumbers('.'))
+
+	keys := ToNaturalSortKeys(data, WithExternalDecimalNumbers('.'))
+	byKey := make([]string, len(data))
+	copy(byKey, data)
+	index := make(map[string]string, len(data))
+	for i, item := range data {
+		index[item] = keys[i]
+	}
+	sort.Slice(byKey, func(i, j int) bool { return index[byKey[i]] < index[byKey[j]] })
+
+	if !reflect.DeepEqual(natural, byKey) {
+		t.Errorf("Key order differs from natural order.\nNatural: %v\nKeys:    %v", natural, byKey)
+	}
+}
+
+// TestSignedNumbers tests direct sorting with signed number recognition
+func TestSignedNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		options  []Option
+		expected []string
+	}{
+		{
+			name:     "always mode orders negatives below zero",
+			input:    []string{"offset5", "offset-2", "offset0", "offset-10", "offset+3"},
+			options:  []Option{WithSignedNumbers(SignAlways)},
+			expected: []string{"offset-10", "offset-2", "offset0", "offset+3", "offset5"},
+		},
+		{
+			name:     "standalone mode ignores signs after letters",
+			input:    []string{"temp -5", "temp 3", "temp -12", "a-5", "a-2"},
+			options:  []Option{WithSignedNumbers(SignStandalone)},
+			expected: []string{"a-2", "a-5", "temp -12", "temp -5", "temp 3"},
+		},
+		{
+			name:     "standalone mode at start of string",
+			input:    []string{"5", "-1", "0", "-20"},
+			options:  []Option{WithSignedNumbers(SignStandalone)},
+			expected: []string{"-20", "-1", "0", "5"},
+		},
+		{
+			name:     "negative zero equals zero",
+			input:    []string{"x-0", "x0", "x-1"},
+			options:  []Option{WithSignedNumbers(SignAlways)},
+			expected: []string{"x-1", "x0", "x-0"},
+		},
+		{
+			name:     "signed decimals",
+			input:    []string{"t-2.10", "t-2.5", "t0.5", "t-0.25"},
+			options:  []Option{WithSignedNumbers(SignAlways), WithDecimalNumbers('.')},
+			expected: []string{"t-2.5", "t-2.10", "t-0.25", "t0.5"},
+		},
+		{
+			name:     "default treats signs as text",
+			input:    []string{"offset5", "offset-2", "offset-10"},
+			options:  nil,
+			expected: []string{"offset5", "offset-2", "offset-10"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, sortFn := range map[string]func([]string, ...Option){
+				"SortStrings":       SortStrings,
+				"SortStringsLegacy": SortStringsLegacy,
+				"SortStringsPooled": SortStringsPooled,
+				"CachedSorter":      func(d []string, o ...Option) { sort.Sort(NewCachedSorter(d, o...)) },
+			} {
+				data := make([]string, len(tt.input))
+				copy(data, tt.input)
+				sortFn(data, tt.options...)
+				if !reflect.DeepEqual(data, tt.expected) {
+					t.Errorf("%s: got %v, want %v", name, data, tt.expected)
+				}
+			}
+		})
+	}
+
+	if _, err := CompareValidated("a", "b", WithSignedNumbers(SignMode(7))); err == nil {
+		t.Error("Expected error for unknown sign mode")
+	}
+}
+
+// TestSignedSortKeys tests that signed sort keys preserve sign ordering
+func TestSignedSortKeys(t *testing.T) {
+	key := ToNaturalSortKey("offset-10", WithMaxNumericLength(3), WithExternalSignedNumbers(SignAlways))
+	if expected := "offset-989"; key != expected {
+		t.Errorf("ToNaturalSortKey() = %q, want %q", key, expected)
+	}
+
+	tests := []struct {
+		name     string
+		data     []string
+		options  []Option
+		external []ExternalSortKeyOption
+	}{
+		{
+			name:     "integers",
+			data:     []string{"offset5", "offset-2", "offset0", "offset-10", "offset-100", "offset42"},
+			options:  []Option{WithSignedNumbers(SignAlways)},
+			external: []ExternalSortKeyOption{WithExternalSignedNumbers(SignAlways)},
+		},
+		{
+			name:     "decimals",
+			data:     []string{"t-2.10", "t-2.5", "t0.5", "t-0.25", "t1", "t-1"},
+			options:  []Option{WithSignedNumbers(SignAlways), WithDecimalNumbers('.')},
+			external: []ExternalSortKeyOption{WithExternalSignedNumbers(SignAlways), WithExternalDecimalNumbers('.')},
+		},
+		{
+			// Fractions longer than MaxNumericLength are not truncated
+			name:     "fractions longer than the width",
+			data:     []string{"-1.5555555555", "-1.55555555555", "-1.555555555551", "1.5555555555", "1.55555555555", "-1.5"},
+			options:  []Option{WithSignedNumbers(SignAlways), WithDecimalNumbers('.')},
+			external: []ExternalSortKeyOption{WithExternalSignedNumbers(SignAlways), WithExternalDecimalNumbers('.')},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			natural := make([]string, len(tt.data))
+			copy(natural, tt.data)
+			SortStrings(natural, tt.options...)
+
+			byKey := make([]string, len(tt.data))
+			copy(byKey, tt.data)
+			sort.Slice(byKey, func(i, j int) bool {
+				return ToNaturalSortKey(byKey[i], tt.external...) < ToNaturalSortKey(byKey[j], tt.external...)
+			})
+
+			if !reflect.DeepEqual(natural, byKey) {
+				t.Errorf("Key order differs from natural order.\nNatural: %v\nKeys:    %v", natural, byKey)
+			}
+		})
+	}
+}
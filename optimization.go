@@ -1,34 +1,88 @@
 package ansort
 
 import (
-	"sort"
-	"sync"
+	"hash/maphash"
+	"unicode"
 )
 
 // TokenCache provides thread-safe caching of tokenized strings
 type TokenCache struct {
-	mu      sync.RWMutex
-	cache   map[string][]Token
+	shards  []tokenCacheShard
+	seed    maphash.Seed
 	maxSize int
+	config  TokenCacheConfig
 }
 
-// NewTokenCache creates a new token cache with the specified maximum size
-func NewTokenCache(maxSize int) *TokenCache {
+// NewTokenCache creates a new token cache with the specified maximum size.
+// By default the least recently used entry is evicted when the cache is
+// full; use WithEvictionStrategy to select another strategy, and
+// WithCacheMaxBytes and WithCacheShards to bound memory and reduce lock
+// contention. Invalid options fall back to their defaults.
+func NewTokenCache(maxSize int, options ...TokenCacheOption) *TokenCache {
+	return newTokenCacheWithConfig(maxSize, buildTokenCacheConfig(options...))
+}
+
+// newTokenCacheWithConfig creates a token cache from a configuration,
+// replacing invalid settings with their defaults
+func newTokenCacheWithConfig(maxSize int, config TokenCacheConfig) *TokenCache {
 	if maxSize <= 0 {
 		maxSize = 1000 // Default cache size
 	}
-	return &TokenCache{
-		cache:   make(map[string][]Token, maxSize),
+	if config.Strategy < EvictionLRU || config.Strategy > EvictionClear {
+		config.Strategy = EvictionLRU
+	}
+	if config.MaxBytes < 0 {
+		config.MaxBytes = 0
+	}
+	if config.Shards <= 0 {
+		config.Shards = 1
+	}
+	if config.Shards > maxSize {
+		config.Shards = maxSize
+	}
+	// Every shard needs at least one byte, since a zero budget is unbounded
+	if config.MaxBytes > 0 && int64(config.Shards) > config.MaxBytes {
+		config.Shards = int(config.MaxBytes)
+	}
+
+	tc := &TokenCache{
+		shards:  make([]tokenCacheShard, config.Shards),
+		seed:    maphash.MakeSeed(),
 		maxSize: maxSize,
+		config:  config,
+	}
+	for i := range tc.shards {
+		shard := &tc.shards[i]
+		shard.strategy = config.Strategy
+		shard.maxSize = int(shardLimit(int64(maxSize), i, config.Shards))
+		shard.maxBytes = shardLimit(config.MaxBytes, i, config.Shards)
+		shard.store = newTokenStore(config.Strategy, shard.maxSize)
+	}
+	return tc
+}
+
+// shardLimit returns shard i's share of a limit split across n shards. The
+// first limit%n shards take one more than the rest, so the shares add up
+// to the limit exactly.
+func shardLimit(limit int64, i, n int) int64 {
+	share := limit / int64(n)
+	if int64(i) < limit%int64(n) {
+		share++
+	}
+	return share
+}
+
+// shard returns the shard holding key
+func (tc *TokenCache) shard(key string) *tokenCacheShard {
+	if len(tc.shards) == 1 {
+		return &tc.shards[0]
 	}
+	return &tc.shards[maphash.String(tc.seed, key)%uint64(len(tc.shards))]
 }
 
 // Get retrieves tokens from cache, returns nil if not found
 func (tc *TokenCache) Get(s string) []Token {
-	tc.mu.RLock()
-	defer tc.mu.RUnlock()
-
-	if tokens, exists := tc.cache[s]; exists {
+	if tokens, exists := tc.shard(s).get(s); exists {
 		// Return a copy to prevent modification of cached data
 		result := make([]Token, len(tokens))
 		copy(result, tokens)
@@ -37,35 +91,74 @@ func (tc *TokenCache) Get(s string) []Token {
 	return nil
 }
 
-// Put stores tokens in cache, evicting oldest entries if necessary
+// Put stores tokens in cache, evicting entries according to the eviction
+// strategy if necessary
 func (tc *TokenCache) Put(s string, tokens []Token) {
-	tc.mu.Lock()
-	defer tc.mu.Unlock()
-
-	// Simple eviction: if cache is full, clear it
-	// In production, this could be LRU or other strategies
-	if len(tc.cache) >= tc.maxSize {
-		tc.cache = make(map[string][]Token, tc.maxSize)
-	}
-
 	// Store a copy to prevent external modification
 	cached := make([]Token, len(tokens))
 	copy(cached, tokens)
-	tc.cache[s] = cached
+	tc.shard(s).put(s, cached)
 }
 
 // Size returns the current cache size
 func (tc *TokenCache) Size() int {
-	tc.mu.RLock()
-	defer tc.mu.RUnlock()
-	return len(tc.cache)
+	size := 0
+	for i := range tc.shards {
+		shard := &tc.shards[i]
+		shard.mu.RLock()
+		size += shard.store.len()
+		shard.mu.RUnlock()
+	}
+	return size
+}
+
+// Bytes returns the memory held by cached entries, as counted by WithCacheMaxBytes
+func (tc *TokenCache) Bytes() int64 {
+	var bytes int64
+	for i := range tc.shards {
+		shard := &tc.shards[i]
+		shard.mu.RLock()
+		bytes += shard.store.bytes()
+		shard.mu.RUnlock()
+	}
+	return bytes
+}
+
+// Strategy returns the eviction strategy of the cache
+func (tc *TokenCache) Strategy() EvictionStrategy {
+	return tc.config.Strategy
+}
+
+// Config returns the configuration of the cache, with invalid options
+// replaced by their defaults
+func (tc *TokenCache) Config() TokenCacheConfig {
+	return tc.config
+}
+
+// ShardStats returns the usage of each shard
+func (tc *TokenCache) ShardStats() []TokenCacheShardStats {
+	stats := make([]TokenCacheShardStats, len(tc.shards))
+	for i := range tc.shards {
+		stats[i] = tc.shards[i].stats()
+	}
+	return stats
+}
+
+// Evictions returns the number of entries evicted to make room since the
+// cache was created
+func (tc *TokenCache) Evictions() int64 {
+	var evictions int64
+	for i := range tc.shards {
+		evictions += tc.shards[i].evictions.Load()
+	}
+	return evictions
 }
 
 // Clear empties the cache
 func (tc *TokenCache) Clear() {
-	tc.mu.Lock()
-	defer tc.mu.Unlock()
-	tc.cache = make(map[string][]Token, tc.maxSize)
+	for i := range tc.shards {
+		tc.shards[i].clear()
+	}
 }
 
 // CachedSorter is an optimized sorter that uses token caching
@@ -131,26 +224,7 @@ func (cs *CachedSorter) compareWithCache(a, b string) int {
 	}
 
 	// Compare token by token
-	minLen := len(tokensA)
-	if len(tokensB) < minLen {
-		minLen = len(tokensB)
-	}
-
-	for i := 0; i < minLen; i++ {
-		result := compareTokensWithConfig(tokensA[i], tokensB[i], cs.config)
-		if result != 0 {
-			return result
-		}
-	}
-
-	// If all compared tokens are equal, the shorter string comes first
-	if len(tokensA) < len(tokensB) {
-		return -1
-	} else if len(tokensA) > len(tokensB) {
-		return 1
-	}
-
-	return 0
+	return compareParsedStrings(a, b, tokensA, tokensB, cs.config)
 }
 
 // parseStringOptimized is an optimized version of parseString with reduced allocations
@@ -213,231 +287,84 @@ func parseStringASCII(s string, tokens []Token) []Token {
 }
 
 // parseStringUnicode handles Unicode strings (fallback)
+// Any rune in Unicode category Nd is treated as a digit.
 func parseStringUnicode(s string, tokens []Token) []Token {
-	runes := []rune(s)
-
-	for i := 0; i < len(runes); {
-		start := i
+	start := 0
+	inNumber := false
+
+	for i, r := range s {
+		isDigit := unicode.IsDigit(r)
+		if i == 0 {
+			inNumber = isDigit
+			continue
+		}
 
-		// Check if current character is a digit
-		if runes[i] >= '0' && runes[i] <= '9' {
-			// Collect all consecutive digits
-			for i < len(runes) && runes[i] >= '0' && runes[i] <= '9' {
-				i++
-			}
-			tokens = append(tokens, Token{
-				Type:  NumericToken,
-				Value: string(runes[start:i]),
-			})
-		} else {
-			// Collect all consecutive non-digits
-			for i < len(runes) && (runes[i] < '0' || runes[i] > '9') {
-				i++
-			}
-			tokens = append(tokens, Token{
-				Type:  AlphaToken,
-				Value: string(runes[start:i]),
-			})
+		// Emit the current token whenever the character class changes
+		if isDigit != inNumber {
+			tokens = append(tokens, newToken(s[start:i], inNumber))
+			start = i
+			inNumber = isDigit
 		}
 	}
 
+	if start < len(s) {
+		tokens = append(tokens, newToken(s[start:], inNumber))
+	}
+
 	return tokens
 }
 
-// shouldUseCaching determines whether to use caching based on dataset characteristics
-func shouldUseCaching(data []string) bool {
-	dataSize := len(data)
-
-	// For small to medium datasets (< 300), cache overhead often outweighs benefits
-	// unless there are specific patterns that benefit from caching
-	if dataSize < 300 {
-		// Check for string repetition patterns that would benefit from caching
-		uniqueStrings := make(map[string]bool)
-		duplicateCount := 0
-
-		// Sample up to 50 strings to avoid expensive analysis
-		sampleSize := dataSize
-		if sampleSize > 50 {
-			sampleSize = 50
-		}
-
-		for i := 0; i < sampleSize; i++ {
-			if uniqueStrings[data[i]] {
-				duplicateCount++
-			} else {
-				uniqueStrings[data[i]] = true
-			}
-		}
-
-		// If we see significant duplicate strings in the sample, caching will help
-		duplicateRatio := float64(duplicateCount) / float64(sampleSize)
-		if duplicateRatio > 0.2 { // More than 20% duplicates
-			return true
-		}
-
-		// Check average string length - much longer strings benefit more from tokenization caching
-		totalLength := 0
-		for i := 0; i < sampleSize; i++ {
-			totalLength += len(data[i])
-		}
-		avgLength := totalLength / sampleSize
-
-		// Very long strings (> 50 chars) with complex patterns benefit from caching
-		if avgLength > 50 {
-			return true
-		}
-
-		// For smaller datasets with typical strings, legacy is often faster due to less overhead
-		return false
+// newToken builds a token of the appropriate type for a parsed segment
+func newToken(value string, numeric bool) Token {
+	if numeric {
+		return Token{Type: NumericToken, Value: value}
 	}
+	return Token{Type: AlphaToken, Value: value}
+}
 
-	// For very large datasets (300+), caching benefits depend on repeated operations
-	// Since single-shot sorting may not benefit much from caching, be conservative
-	return true // Still default to caching for large datasets, but the threshold is higher
+// shouldUseCaching determines whether to use caching based on dataset
+// characteristics; see planAutoSort
+func shouldUseCaching(data []string) bool {
+	return planAutoSort(data).Strategy == StrategyCached
 }
 
-// SortStringsOptimized provides an optimized sorting function with intelligent caching
+// SortStringsOptimized provides an optimized sorting function with intelligent caching.
+// It delegates to the default collator, which caches tokenizations when
+// shouldUseCaching expects it to pay off and falls back to uncached parsing
+// when caching is disabled. Use WithStrategy to pin the strategy and
+// PlanSort to inspect the choice.
 func SortStringsOptimized(data []string, options ...Option) {
-	if len(data) <= 1 {
-		return
-	}
-
-	// Check if caching is disabled - fallback to legacy implementation
-	if globalCacheDisabled {
-		SortStringsLegacy(data, options...)
-		return
-	}
-
-	// Intelligent auto-selection based on dataset size and characteristics
-	// For small datasets, cache overhead may outweigh benefits
-	if shouldUseCaching(data) {
-		// Use cached sorter for better performance on larger datasets
-		sorter := NewCachedSorter(data, options...)
-		sort.Sort(sorter)
-	} else {
-		// Use legacy implementation for small datasets where cache overhead dominates
-		SortStringsLegacy(data, options...)
-	}
+	defaultCollator.sortWithConfig(data, buildConfig(options...))
 }
 
-// CompareOptimized provides optimized comparison with adaptive caching
-var globalCache = NewTokenCache(2000)
-var cacheHits, cacheMisses int64
-var adaptiveCachingEnabled = true
-
+// CompareOptimized provides optimized comparison with adaptive caching, using
+// the default collator's cache
 func CompareOptimized(a, b string, options ...Option) int {
 	// Handle identical strings quickly
 	if a == b {
 		return 0
 	}
-
-	// Check if caching is disabled - fallback to legacy implementation
-	if globalCacheDisabled {
-		return CompareLegacy(a, b, options...)
-	}
-
-	// For short strings, cache overhead may not be worth it
-	if adaptiveCachingEnabled && len(a) < 10 && len(b) < 10 {
-		// Use optimized parsing but skip caching for very short strings
-		return compareWithoutCache(a, b, options...)
-	}
-
-	config := buildConfig(options...)
-
-	// Try to get tokens from global cache
-	tokensA := globalCache.Get(a)
-	if tokensA == nil {
-		tokensA = parseStringOptimized(a)
-		globalCache.Put(a, tokensA)
-		cacheMisses++
-	} else {
-		cacheHits++
-	}
-
-	tokensB := globalCache.Get(b)
-	if tokensB == nil {
-		tokensB = parseStringOptimized(b)
-		globalCache.Put(b, tokensB)
-		cacheMisses++
-	} else {
-		cacheHits++
-	}
-
-	// Compare token by token
-	minLen := len(tokensA)
-	if len(tokensB) < minLen {
-		minLen = len(tokensB)
-	}
-
-	for i := 0; i < minLen; i++ {
-		result := compareTokensWithConfig(tokensA[i], tokensB[i], config)
-		if result != 0 {
-			return result
-		}
-	}
-
-	// If all compared tokens are equal, the shorter string comes first
-	if len(tokensA) < len(tokensB) {
-		return -1
-	} else if len(tokensA) > len(tokensB) {
-		return 1
-	}
-
-	return 0
-}
-
-// compareWithoutCache performs optimized comparison without caching
-func compareWithoutCache(a, b string, options ...Option) int {
-	config := buildConfig(options...)
-
-	// Use optimized parsing but don't cache results
-	tokensA := parseStringOptimized(a)
-	tokensB := parseStringOptimized(b)
-
-	// Compare token by token
-	minLen := len(tokensA)
-	if len(tokensB) < minLen {
-		minLen = len(tokensB)
-	}
-
-	for i := 0; i < minLen; i++ {
-		result := compareTokensWithConfig(tokensA[i], tokensB[i], config)
-		if result != 0 {
-			return result
-		}
-	}
-
-	// If all compared tokens are equal, the shorter string comes first
-	if len(tokensA) < len(tokensB) {
-		return -1
-	} else if len(tokensA) > len(tokensB) {
-		return 1
-	}
-
-	return 0
+	return defaultCollator.compareWithConfig(a, b, buildConfig(options...))
 }
 
 // ClearGlobalCache clears the global comparison cache
 func ClearGlobalCache() {
-	globalCache.Clear()
+	defaultCollator.ClearCache()
 }
 
 // GlobalCacheStats returns statistics about the global cache
 func GlobalCacheStats() (size int, maxSize int) {
-	return globalCache.Size(), globalCache.maxSize
+	stats := defaultCollator.Stats()
+	return stats.CacheSize, stats.CacheMaxSize
 }
 
 // CacheEfficiencyStats returns cache hit/miss statistics
 func CacheEfficiencyStats() (hits int64, misses int64, hitRatio float64) {
-	total := cacheHits + cacheMisses
-	if total == 0 {
-		return 0, 0, 0.0
-	}
-	return cacheHits, cacheMisses, float64(cacheHits) / float64(total)
+	stats := defaultCollator.Stats()
+	return stats.Hits, stats.Misses, stats.HitRatio
 }
 
 // ResetCacheStats resets the cache hit/miss counters
 func ResetCacheStats() {
-	cacheHits = 0
-	cacheMisses = 0
+	defaultCollator.ResetStats()
 }
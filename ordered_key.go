@@ -0,0 +1,189 @@
+package ansort
+
+import (
+	"strconv"
+	"strings"
+)
+
+// KeyScheme selects the encoding used to generate external sort keys
+type KeyScheme int
+
+const (
+	// KeySchemePadded pads numeric segments with leading zeros to MaxNumericLength.
+	// Keys are human-readable, but numbers longer than MaxNumericLength and text
+	// containing characters that sort below '0' may not match Compare.
+	// This is the default scheme and stays compatible with previously stored keys.
+	KeySchemePadded KeyScheme = iota
+	// KeySchemeOrdered encodes every token with a type marker, numbers with a
+	// length prefix and text with an escaped terminator. Sorting these keys
+	// lexicographically always gives the same order as Compare with the
+	// equivalent options, for numbers of any length and any characters.
+	KeySchemeOrdered
+	// KeySchemeCompact writes each number as a length-class prefix followed by
+	// its significant digits ("a1b2c3" becomes "a11b12c13"). Keys are much
+	// shorter than padded keys and order like KeySchemePadded with unlimited
+	// MaxNumericLength, including its limitations for leading zeros and text
+	// that sorts below '0'.
+	KeySchemeCompact
+)
+
+// Markers used by KeySchemeOrdered. End of key sorts below every marker, so
+// strings with fewer tokens come first, and numeric tokens sort before text.
+const (
+	orderedNumericMarker  = '1'
+	orderedAlphaMarker    = '2'
+	orderedOriginalMarker = '3'
+	orderedNegativeMarker = 'n'
+	orderedPositiveMarker = 'p'
+
+	// orderedEscape introduces an escaped low byte inside text. Bytes up to and
+	// including orderedEscape are written as orderedEscape followed by the byte
+	// shifted by orderedEscapeShift, which keeps their relative order.
+	orderedEscape      = '!'
+	orderedEscapeShift = 0x23
+	// orderedTerminator ends escaped text and sorts below any escaped or plain byte
+	orderedTerminator = "!\""
+	// orderedFractionEnd ends the fractional digits of a decimal number
+	orderedFractionEnd = '!'
+	// orderedComplementBase mirrors the printable range '!'..'~' to reverse
+	// the order of negative number magnitudes
+	orderedComplementBase = '!' + '~'
+)
+
+// WithKeyScheme selects the encoding used for external sort keys.
+// The default KeySchemePadded keeps existing stored keys valid; use
+// KeySchemeOrdered when keys must match Compare for every possible input, or
+// KeySchemeCompact when key size matters most.
+//
+// Example:
+//
+//	sortKey := ansort.ToNaturalSortKey("item12345678901",
+//		ansort.WithKeyScheme(ansort.KeySchemeOrdered))
+//	// Keys order "item9" before "item12345678901", like Compare
+func WithKeyScheme(scheme KeyScheme) ExternalSortKeyOption {
+	return func(c *ExternalSortKeyConfig) {
+		c.KeyScheme = scheme
+	}
+}
+
+// validateKeyScheme validates the sort key encoding scheme
+// Returns an error if the scheme is unknown
+func validateKeyScheme(scheme KeyScheme) error {
+	if scheme < KeySchemePadded || scheme > KeySchemeCompact {
+		return &ValidationError{
+			Field:   "KeyScheme",
+			Message: "must be KeySchemePadded, KeySchemeOrdered or KeySchemeCompact",
+		}
+	}
+	return nil
+}
+
+// generateOrderedSortKey generates a KeySchemeOrdered sort key.
+//
+// Each token is written as a type marker followed by its encoding:
+//   - Text is written (lower-cased when case-insensitive) with low bytes
+//     escaped, followed by a terminator that sorts below any text byte.
+//   - Numbers are written as an optional sign marker, the count of
+//     significant digits (itself length-prefixed), the significant digits,
+//     and the fractional digits in decimal mode. Negative magnitudes are
+//     complemented so that larger magnitudes sort first. The magnitude is
+//     followed by the tie-break Compare uses for equal values: the digit
+//     count, and the original text when it cannot be derived from the rest.
+func generateOrderedSortKey(input string, config ExternalSortKeyConfig) string {
+	format := config.numberFormat()
+	tokens := refineTokens(parseString(input), format)
+
+	var result strings.Builder
+	result.Grow(len(input) * 2)
+
+	for _, token := range tokens {
+		if token.Type == NumericToken {
+			result.WriteByte(orderedNumericMarker)
+			writeOrderedNumber(&result, token.Value, format)
+		} else {
+			alphaValue := token.Value
+			if !config.CaseSensitive {
+				alphaValue = strings.ToLower(alphaValue)
+			}
+			result.WriteByte(orderedAlphaMarker)
+			writeOrderedText(&result, alphaValue)
+		}
+	}
+
+	return result.String()
+}
+
+// writeOrderedNumber writes the KeySchemeOrdered encoding of a numeric token
+func writeOrderedNumber(result *strings.Builder, value string, format numberFormat) {
+	normalized := normalizeDigits(value)
+	negative, magnitude := splitSign(normalized)
+	if format.signMode == SignNone {
+		negative, magnitude = false, normalized
+	}
+	negative = negative && !isZeroMagnitude(magnitude, format.decimalSeparator)
+
+	integer, fraction := splitDecimal(magnitude, format.decimalSeparator)
+	integer = strings.TrimLeft(integer, "0")
+
+	var encoded strings.Builder
+	writeOrderedLength(&encoded, len(integer))
+	encoded.WriteString(integer)
+	if format.decimalSeparator != 0 {
+		encoded.WriteString(strings.TrimRight(fraction, "0"))
+		encoded.WriteByte(orderedFractionEnd)
+	}
+
+	if format.signMode != SignNone {
+		if negative {
+			result.WriteByte(orderedNegativeMarker)
+			result.WriteString(complementOrdered(encoded.String()))
+		} else {
+			result.WriteByte(orderedPositiveMarker)
+			result.WriteString(encoded.String())
+		}
+	} else {
+		result.WriteString(encoded.String())
+	}
+
+	// Tie-break for numerically equal values: fewer characters first, then
+	// the original text. ASCII integers with equal value and length are
+	// identical, so their original text is omitted.
+	writeOrderedLength(result, len(normalized))
+	if format != (numberFormat{}) || !isASCII(value) {
+		result.WriteByte(orderedOriginalMarker)
+		writeOrderedText(result, value)
+	}
+}
+
+// writeOrderedLength writes a non-negative integer so that lexicographic
+// order matches numeric order: the number of decimal digits as a single
+// character, followed by the digits themselves
+func writeOrderedLength(result *strings.Builder, n int) {
+	digits := strconv.Itoa(n)
+	result.WriteByte(byte('0' + len(digits)))
+	result.WriteString(digits)
+}
+
+// writeOrderedText writes text with bytes at or below orderedEscape escaped,
+// followed by orderedTerminator
+func writeOrderedText(result *strings.Builder, text string) {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c <= orderedEscape {
+			result.WriteByte(orderedEscape)
+			result.WriteByte(c + orderedEscapeShift)
+		} else {
+			result.WriteByte(c)
+		}
+	}
+	result.WriteString(orderedTerminator)
+}
+
+// complementOrdered reverses the lexicographic order of a printable ASCII encoding
+func complementOrdered(encoded string) string {
+	complemented := []byte(encoded)
+	for i, c := range complemented {
+		complemented[i] = orderedComplementBase - c
+	}
+	return string(complemented)
+}
@@ -0,0 +1,100 @@
+package ansort
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// randomKeyTestString builds a random string from characters that exercise
+// numbers, case, punctuation below '0', escape bytes and Unicode digits
+func randomKeyTestString(rng *rand.Rand) string {
+	alphabet := []string{
+		"a", "b", "A", "B", "z", "é", "0", "1", "2", "9", "00", "123456789012345678901",
+		"-", "+", ".", ",", " ", "!", "\"", "#", "\x00", "\x01", "~", "٣", "１", "_",
+	}
+	n := rng.Intn(8)
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteString(alphabet[rng.Intn(len(alphabet))])
+	}
+	return b.String()
+}
+
+// TestOrderedKeySchemeMatchesCompare verifies that ordered keys sort exactly like Compare
+func TestOrderedKeySchemeMatchesCompare(t *testing.T) {
+	configs := map[string][]ExternalSortKeyOption{
+		"default":          nil,
+		"case insensitive": {WithExternalCaseInsensitive()},
+		"decimal":          {WithExternalDecimalNumbers('.')},
+		"decimal comma":    {WithExternalDecimalNumbers(',')},
+		"signed":           {WithExternalSignedNumbers(SignAlways)},
+		"signed standalone decimal": {
+			WithExternalSignedNumbers(SignStandalone), WithExternalDecimalNumbers('.'), WithExternalCaseInsensitive(),
+		},
+	}
+
+	rng := rand.New(rand.NewSource(42))
+	for name, options := range configs {
+		t.Run(name, func(t *testing.T) {
+			options := append([]ExternalSortKeyOption{WithKeyScheme(KeySchemeOrdered)}, options...)
+			compareOptions := []Option{withConfig(buildExternalSortKeyConfig(options...).compareConfig())}
+
+			for i := 0; i < 5000; i++ {
+				a := randomKeyTestString(rng)
+				b := randomKeyTestString(rng)
+
+				expected := CompareLegacy(a, b, compareOptions...)
+				got := strings.Compare(ToNaturalSortKey(a, options...), ToNaturalSortKey(b, options...))
+				if got != expected {
+					t.Fatalf("key order %d differs from Compare %d for %q vs %q\nkeys: %q vs %q",
+						got, expected, a, b, ToNaturalSortKey(a, options...), ToNaturalSortKey(b, options...))
+				}
+			}
+		})
+	}
+}
+
+// TestOrderedKeySchemeCases tests the specific inputs the padded scheme gets wrong
+func TestOrderedKeySchemeCases(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+	}{
+		{"number longer than padding", []string{"item12345678901", "item9", "item99999999999999999999999"}},
+		{"punctuation below zero", []string{"a-1", "a1", "a 1", "a"}},
+		{"leading zero tie-break", []string{"a01a", "a1b", "a1", "a001"}},
+		{"versions", []string{"v1.10.2", "v1.2.10", "v1.2.3", "v1.2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			natural := make([]string, len(tt.input))
+			copy(natural, tt.input)
+			SortStrings(natural)
+
+			byKey := make([]string, len(tt.input))
+			copy(byKey, tt.input)
+			sort.Slice(byKey, func(i, j int) bool {
+				return ToNaturalSortKey(byKey[i], WithKeyScheme(KeySchemeOrdered)) <
+					ToNaturalSortKey(byKey[j], WithKeyScheme(KeySchemeOrdered))
+			})
+
+			for i := range natural {
+				if natural[i] != byKey[i] {
+					t.Fatalf("Key order differs from natural order.\nNatural: %q\nKeys:    %q", natural, byKey)
+				}
+			}
+		})
+	}
+
+	if _, err := ToNaturalSortKeyValidated("a1", WithKeyScheme(KeyScheme(9))); err == nil {
+		t.Error("Expected error for unknown key scheme")
+	}
+
+	// The default scheme is unchanged so existing stored keys keep working
+	if key := ToNaturalSortKey("file10.txt"); key != "file0000000010.txt" {
+		t.Errorf("Default key changed: %q", key)
+	}
+}
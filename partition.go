@@ -0,0 +1,253 @@
+package ansort
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Partitioning splits a natural key space into shards of roughly equal size.
+// Shard 0 holds items before Splits[0], shard i holds items from Splits[i-1]
+// up to but excluding Splits[i], and the last shard holds the rest.
+type Partitioning struct {
+	// Splits are the N-1 split points in natural order. Splits may repeat
+	// when the data has fewer distinct values than shards, leaving empty shards.
+	Splits []string
+	// KeyBoundaries are the external sort keys of Splits, so shards can be
+	// selected with key ranges: KeyBoundaries[i-1] <= key < KeyBoundaries[i].
+	// Descending keys are not supported, since their ranges run the other way.
+	KeyBoundaries []string
+
+	config Config
+}
+
+// Shard returns the index of the shard that holds the input
+func (p *Partitioning) Shard(input string) int {
+	return sort.Search(len(p.Splits), func(i int) bool {
+		return Compare(input, p.Splits[i], withConfig(p.config)) < 0
+	})
+}
+
+// Partition picks n-1 split points from a sample so that each of the n shards
+// holds roughly the same number of sample items in natural order. The split
+// points are also returned as external sort keys generated with the options;
+// use KeySchemeOrdered for key ranges that match natural order exactly.
+// For inputs too big to hold in memory, use NewPartitionSketch.
+//
+// Returns an error if the sample is nil or empty, n is less than 1, the
+// configuration is invalid or requests descending keys.
+//
+// Example:
+//
+//	p, err := ansort.Partition(sampleNames, 8, ansort.WithKeyScheme(ansort.KeySchemeOrdered))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	// Worker i backfills rows with p.KeyBoundaries[i-1] <= sort_key < p.KeyBoundaries[i]
+func Partition(sample []string, n int, options ...ExternalSortKeyOption) (*Partitioning, error) {
+	if err := validateSlice(sample, "Partition"); err != nil {
+		return nil, err
+	}
+	config := buildExternalSortKeyConfig(options...)
+	if err := validatePartitionInput(len(sample), n, config); err != nil {
+		return nil, err
+	}
+
+	items := make([]weightedItem, len(sample))
+	for i, value := range sample {
+		items[i] = weightedItem{value: value, weight: 1}
+	}
+	return partitionWeighted(items, n, config), nil
+}
+
+// PartitionSketch is a streaming quantile sketch that computes partitions of
+// inputs too big to hold in memory. It keeps at most a few times capacity
+// items: whenever a level fills up, its items are sorted and every other one
+// is promoted to the next level with twice the weight. Larger capacities give
+// more balanced shards; the rank error shrinks roughly in proportion to
+// capacity.
+//
+// A PartitionSketch is not safe for concurrent use. Compaction uses a fixed
+// random seed, so the same input stream always gives the same partitions.
+type PartitionSketch struct {
+	config   ExternalSortKeyConfig
+	capacity int
+	levels   [][]string
+	count    int
+	rng      *rand.Rand
+}
+
+// NewPartitionSketch creates a streaming partition sketch that holds about
+// capacity items per level. Capacity is rounded up to an even number.
+//
+// Returns an error if capacity is less than 2, the configuration is invalid
+// or requests descending keys.
+//
+// Example:
+//
+//	sketch, err := ansort.NewPartitionSketch(1024, ansort.WithKeyScheme(ansort.KeySchemeOrdered))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for rows.Next() {
+//		sketch.Add(name)
+//	}
+//	p, err := sketch.Partition(16)
+func NewPartitionSketch(capacity int, options ...ExternalSortKeyOption) (*PartitionSketch, error) {
+	if capacity < 2 {
+		return nil, &ValidationError{
+			Field:   "capacity",
+			Message: "must be at least 2",
+		}
+	}
+	config := buildExternalSortKeyConfig(options...)
+	if err := validatePartitionConfig(config); err != nil {
+		return nil, err
+	}
+
+	// An even capacity keeps the total weight equal to the item count
+	capacity += capacity % 2
+	return &PartitionSketch{
+		config:   config,
+		capacity: capacity,
+		levels:   [][]string{make([]string, 0, capacity)},
+		rng:      rand.New(rand.NewSource(1)),
+	}, nil
+}
+
+// Add adds items to the sketch
+func (s *PartitionSketch) Add(items ...string) {
+	for _, item := range items {
+		s.levels[0] = append(s.levels[0], item)
+		s.count++
+		for level := 0; len(s.levels[level]) >= s.capacity; level++ {
+			s.compact(level)
+		}
+	}
+}
+
+// Count returns the number of items added to the sketch
+func (s *PartitionSketch) Count() int {
+	return s.count
+}
+
+// Partition picks n-1 split points so that each of the n shards holds roughly
+// the same number of the items added so far.
+//
+// Returns an error if no items were added or n is less than 1.
+func (s *PartitionSketch) Partition(n int) (*Partitioning, error) {
+	if err := validatePartitionInput(s.count, n, s.config); err != nil {
+		return nil, err
+	}
+
+	var items []weightedItem
+	for level, values := range s.levels {
+		for _, value := range values {
+			items = append(items, weightedItem{value: value, weight: 1 << level})
+		}
+	}
+	return partitionWeighted(items, n, s.config), nil
+}
+
+// compact sorts a full level and promotes every other item, starting at a
+// random offset, to the next level
+func (s *PartitionSketch) compact(level int) {
+	if level+1 == len(s.levels) {
+		s.levels = append(s.levels, make([]string, 0, s.capacity))
+	}
+
+	values := s.levels[level]
+	sortUncached(values, s.config.compareConfig())
+	for i := s.rng.Intn(2); i < len(values); i += 2 {
+		s.levels[level+1] = append(s.levels[level+1], values[i])
+	}
+	s.levels[level] = values[:0]
+}
+
+// sortUncached sorts values in natural order, tokenizing each value once.
+// Unlike SortStrings it bypasses the default collator, so partitioning does
+// not fill the shared token cache or count toward the global metrics.
+func sortUncached(values []string, config Config) {
+	items := make([]tokenizedString, len(values))
+	for i, value := range values {
+		items[i] = tokenizedString{s: value, tokens: parseString(value)}
+	}
+	sort.Sort(tokenizedSorter{items: items, config: config})
+	for i := range items {
+		values[i] = items[i].s
+	}
+}
+
+// weightedItem is a sample item standing for weight items of the input
+type weightedItem struct {
+	value  string
+	weight int
+	tokens []Token
+}
+
+// validatePartitionInput validates the sample size, shard count and configuration
+func validatePartitionInput(count, n int, config ExternalSortKeyConfig) error {
+	if count == 0 {
+		return &ValidationError{
+			Field:   "sample",
+			Message: "must contain at least one item",
+		}
+	}
+	if n < 1 {
+		return &ValidationError{
+			Field:   "n",
+			Message: "must be at least 1",
+		}
+	}
+	return validatePartitionConfig(config)
+}
+
+// validatePartitionConfig validates the key configuration of a partitioning
+func validatePartitionConfig(config ExternalSortKeyConfig) error {
+	if err := validateExternalSortKeyConfig(config); err != nil {
+		return err
+	}
+	if config.Descending {
+		return &ValidationError{
+			Field:   "Descending",
+			Message: "descending keys are not supported by partitions; partition with ascending keys",
+		}
+	}
+	return nil
+}
+
+// partitionWeighted picks split points from weighted items. Split i is the
+// first item, in natural order, whose cumulative weight exceeds i/n of the
+// total weight.
+func partitionWeighted(items []weightedItem, n int, config ExternalSortKeyConfig) *Partitioning {
+	// Sort with private tokenizations, as sortUncached does
+	compareConfig := config.compareConfig()
+	for i := range items {
+		items[i].tokens = parseString(items[i].value)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		return compareTokenized(tokenizedString{a.value, a.tokens}, tokenizedString{b.value, b.tokens}, compareConfig) < 0
+	})
+
+	total := 0
+	for _, item := range items {
+		total += item.weight
+	}
+
+	p := &Partitioning{
+		Splits:        make([]string, 0, n-1),
+		KeyBoundaries: make([]string, 0, n-1),
+		config:        compareConfig,
+	}
+	cumulative := 0
+	next := 1
+	for _, item := range items {
+		cumulative += item.weight
+		for next < n && cumulative*n > next*total {
+			p.Splits = append(p.Splits, item.value)
+			p.KeyBoundaries = append(p.KeyBoundaries, generateSortKeyWithConfig(item.value, config))
+			next++
+		}
+	}
+	return p
+}
@@ -0,0 +1,162 @@
+package ansort
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// TestPartition tests balanced split points from an in-memory sample
+func TestPartition(t *testing.T) {
+	var sample []string
+	for i := 99; i >= 0; i-- {
+		sample = append(sample, fmt.Sprintf("file%d", i))
+	}
+
+	p, err := Partition(sample, 4, WithKeyScheme(KeySchemeOrdered))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"file25", "file50", "file75"}
+	if !reflect.DeepEqual(p.Splits, expected) {
+		t.Errorf("Splits = %v, want %v", p.Splits, expected)
+	}
+	for i, split := range p.Splits {
+		if p.KeyBoundaries[i] != ToNaturalSortKey(split, WithKeyScheme(KeySchemeOrdered)) {
+			t.Errorf("KeyBoundaries[%d] = %q does not match the key of %q", i, p.KeyBoundaries[i], split)
+		}
+	}
+
+	counts := make([]int, 4)
+	for _, item := range sample {
+		shard := p.Shard(item)
+		counts[shard]++
+
+		// Key ranges select the same shard
+		key := ToNaturalSortKey(item, WithKeyScheme(KeySchemeOrdered))
+		keyShard := 0
+		for keyShard < len(p.KeyBoundaries) && key >= p.KeyBoundaries[keyShard] {
+			keyShard++
+		}
+		if keyShard != shard {
+			t.Errorf("Item %q is in shard %d but its key selects shard %d", item, shard, keyShard)
+		}
+	}
+	if !reflect.DeepEqual(counts, []int{25, 25, 25, 25}) {
+		t.Errorf("Shard sizes = %v, want 25 each", counts)
+	}
+
+	// A single shard needs no split points
+	p, err = Partition(sample, 1)
+	if err != nil || len(p.Splits) != 0 || p.Shard("anything") != 0 {
+		t.Errorf("Partition(sample, 1) = %+v, %v", p, err)
+	}
+
+	// Fewer distinct values than shards repeat split points
+	p, err = Partition([]string{"a", "a", "a", "b"}, 4)
+	if err != nil || !reflect.DeepEqual(p.Splits, []string{"a", "a", "b"}) {
+		t.Errorf("Partition() with duplicates = %v, %v", p.Splits, err)
+	}
+}
+
+// TestPartitionValidation tests input validation
+func TestPartitionValidation(t *testing.T) {
+	if _, err := Partition(nil, 2); err == nil {
+		t.Error("Expected error for nil sample")
+	}
+	if _, err := Partition([]string{}, 2); err == nil {
+		t.Error("Expected error for empty sample")
+	}
+	if _, err := Partition([]string{"a"}, 0); err == nil {
+		t.Error("Expected error for zero shards")
+	}
+	if _, err := Partition([]string{"a"}, 2, WithMaxNumericLength(0)); err == nil {
+		t.Error("Expected error for invalid configuration")
+	}
+	if _, err := NewPartitionSketch(1); err == nil {
+		t.Error("Expected error for sketch capacity below 2")
+	}
+
+	// Descending key ranges would not match the shards
+	var validationErr *ValidationError
+	if _, err := Partition([]string{"a1", "a2"}, 2, WithDescendingKey()); !errors.As(err, &validationErr) || validationErr.Field != "Descending" {
+		t.Errorf("Partition() with descending keys: error = %v, want ValidationError", err)
+	}
+	if _, err := NewPartitionSketch(16, WithDescendingKey()); !errors.As(err, &validationErr) || validationErr.Field != "Descending" {
+		t.Errorf("NewPartitionSketch() with descending keys: error = %v, want ValidationError", err)
+	}
+
+	sketch, _ := NewPartitionSketch(16)
+	if _, err := sketch.Partition(2); err == nil {
+		t.Error("Expected error for empty sketch")
+	}
+}
+
+// TestPartitionSketch verifies that streaming partitions are roughly balanced
+func TestPartitionSketch(t *testing.T) {
+	const items = 100000
+	const shards = 8
+
+	sketch, err := NewPartitionSketch(512, WithExternalCaseInsensitive())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(18))
+	inputs := make([]string, items)
+	for i := range inputs {
+		inputs[i] = fmt.Sprintf("%c%d", 'a'+rng.Intn(3), rng.Intn(1000000))
+		sketch.Add(inputs[i])
+	}
+	if sketch.Count() != items {
+		t.Errorf("Count() = %d, want %d", sketch.Count(), items)
+	}
+
+	p, err := sketch.Partition(shards)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(p.Splits) != shards-1 || len(p.KeyBoundaries) != shards-1 {
+		t.Fatalf("Got %d splits and %d boundaries, want %d", len(p.Splits), len(p.KeyBoundaries), shards-1)
+	}
+	for i := 1; i < len(p.Splits); i++ {
+		if Compare(p.Splits[i-1], p.Splits[i], WithCaseInsensitive()) > 0 {
+			t.Errorf("Splits out of order: %q > %q", p.Splits[i-1], p.Splits[i])
+		}
+	}
+
+	counts := make([]int, shards)
+	for _, input := range inputs {
+		counts[p.Shard(input)]++
+	}
+	for i, count := range counts {
+		if count < items/shards*95/100 || count > items/shards*105/100 {
+			t.Errorf("Shard %d holds %d items, want about %d (all shards: %v)", i, count, items/shards, counts)
+		}
+	}
+}
+
+// TestPartitionLeavesGlobalMetrics tests that partitioning does not use the
+// shared token cache of the package-level functions
+func TestPartitionLeavesGlobalMetrics(t *testing.T) {
+	before := GlobalMetrics()
+
+	sketch, _ := NewPartitionSketch(64)
+	var sample []string
+	for i := 0; i < 1000; i++ {
+		sample = append(sample, fmt.Sprintf("partition_item_%d", i))
+	}
+	sketch.Add(sample...)
+	if _, err := sketch.Partition(4); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := Partition(sample, 4); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if after := GlobalMetrics(); !reflect.DeepEqual(after, before) {
+		t.Errorf("Global metrics changed from %+v to %+v", before, after)
+	}
+}
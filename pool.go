@@ -36,30 +36,9 @@ func (tp *TokenPool) Put(tokens []Token) {
 	}
 }
 
-// Global token pool for reuse across functions
-var globalTokenPool = NewTokenPool()
-
-// parseStringPooled uses the global token pool to reduce allocations
+// parseStringPooled tokenizes a string using the default collator's token pool
 func parseStringPooled(s string) []Token {
-	if len(s) == 0 {
-		return []Token{}
-	}
-
-	// Get a token slice from the pool
-	tokens := globalTokenPool.Get()
-	defer globalTokenPool.Put(tokens)
-
-	// Parse using the pooled slice
-	if isASCII(s) {
-		tokens = parseStringASCII(s, tokens)
-	} else {
-		tokens = parseStringUnicode(s, tokens)
-	}
-
-	// Return a copy since we're returning the pooled slice
-	result := make([]Token, len(tokens))
-	copy(result, tokens)
-	return result
+	return defaultCollator.parse(s)
 }
 
 // PooledSorter uses memory pools for optimal performance
@@ -117,26 +96,7 @@ func (ps *PooledSorter) comparePooled(a, b string) int {
 	}
 
 	// Compare token by token
-	minLen := len(tokensA)
-	if len(tokensB) < minLen {
-		minLen = len(tokensB)
-	}
-
-	for i := 0; i < minLen; i++ {
-		result := compareTokensWithConfig(tokensA[i], tokensB[i], ps.config)
-		if result != 0 {
-			return result
-		}
-	}
-
-	// If all compared tokens are equal, the shorter string comes first
-	if len(tokensA) < len(tokensB) {
-		return -1
-	} else if len(tokensA) > len(tokensB) {
-		return 1
-	}
-
-	return 0
+	return compareParsedStrings(a, b, tokensA, tokensB, ps.config)
 }
 
 // parseWithPool uses the sorter's token pool for parsing
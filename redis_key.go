@@ -0,0 +1,107 @@
+package ansort
+
+import (
+	"encoding/binary"
+)
+
+// NaturalScorePrecisionBytes is the number of leading sort key bytes that
+// ToNaturalScore always distinguishes. Inputs whose keys only differ after
+// these bytes may receive equal scores, but never reversed ones.
+const NaturalScorePrecisionBytes = 6
+
+// LexRange holds ZRANGEBYLEX-compatible bounds, including their '[' or '('
+// prefix, ready to pass to ZRANGEBYLEX, ZREVRANGEBYLEX, ZLEXCOUNT or
+// ZREMRANGEBYLEX
+type LexRange struct {
+	Min string
+	Max string
+}
+
+// ToNaturalScore returns a Redis sorted-set score that follows natural order.
+// The score is derived from the first 8 bytes of the external sort key
+// generated with the same options, so scores never contradict key order:
+// inputs whose keys differ within the first NaturalScorePrecisionBytes bytes
+// get distinct scores in natural order, while longer common prefixes may tie.
+// Redis orders members with equal scores by their bytes.
+//
+// Key bytes are spent on the encoding as well as the input, so the number of
+// input characters covered depends on the scheme: KeySchemeCompact uses the
+// fewest bytes per number, and headers should not be used with scores.
+//
+// Example:
+//
+//	score := ansort.ToNaturalScore("item10", ansort.WithKeyScheme(ansort.KeySchemeCompact))
+//	// ZADD leaderboard <score> item10; "item9" scores lower, "item100" higher
+func ToNaturalScore(input string, options ...ExternalSortKeyOption) float64 {
+	config := buildExternalSortKeyConfig(options...)
+	return naturalScore(generateSortKeyWithConfig(input, config))
+}
+
+// naturalScore converts the first 8 bytes of a key into a float64.
+// Shorter keys are padded with zero bytes. Converting a uint64 to float64
+// rounds to nearest, which never reverses order, and keeps at least the 53
+// most significant bits, so the first NaturalScorePrecisionBytes bytes
+// always survive.
+func naturalScore(key string) float64 {
+	var buffer [8]byte
+	copy(buffer[:], key)
+	return float64(binary.BigEndian.Uint64(buffer[:]))
+}
+
+// NaturalLexRange builds ZRANGEBYLEX bounds selecting the members between min
+// and max in natural order, both inclusive, from a sorted set whose members
+// are sort keys generated with the same options and added with equal scores.
+// Members that compare equal to min or max, such as other casings with
+// WithExternalCaseInsensitive and WithLosslessKey, are included.
+//
+// Use "-" or "+" in place of Min or Max for an unbounded range. With
+// KeySchemeOrdered the range matches Compare exactly; other schemes carry
+// their usual limitations. Descending and length-bounded keys are not supported.
+//
+// Example:
+//
+//	r, err := ansort.NaturalLexRange("file5", "file20",
+//		ansort.WithKeyScheme(ansort.KeySchemeOrdered), ansort.WithLosslessKey())
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	// ZRANGEBYLEX files <r.Min> <r.Max>
+//	// Returns file5, file6, ..., file20 (decode members with FromNaturalSortKey)
+func NaturalLexRange(min, max string, options ...ExternalSortKeyOption) (LexRange, error) {
+	config := buildExternalSortKeyConfig(options...)
+	if err := validateExternalSortKeyConfig(config); err != nil {
+		return LexRange{}, err
+	}
+	if config.Descending {
+		return LexRange{}, &ValidationError{
+			Field:   "Descending",
+			Message: "descending keys are not supported by NaturalLexRange; use ZREVRANGEBYLEX with ascending keys",
+		}
+	}
+	if config.MaxKeyBytes > 0 {
+		return LexRange{}, &ValidationError{
+			Field:   "MaxKeyBytes",
+			Message: "length-bounded keys are not supported by NaturalLexRange",
+		}
+	}
+
+	// Bound on the natural part of the keys. Members with a trailing lossless
+	// section continue with losslessSeparator, so every member that compares
+	// equal to max sorts below the natural part followed by the next byte.
+	natural := config
+	natural.KeyScheme = config.keyScheme()
+	natural.Lossless = false
+	natural.CaseTieBreak = false
+	natural.Header = false
+	var header string
+	if config.Header {
+		header = keyHeader(config)
+	}
+	minKey := header + generateSortKeyWithConfig(min, natural)
+	maxKey := header + generateSortKeyWithConfig(max, natural)
+
+	if config.keyFormat().Lossless {
+		return LexRange{Min: "[" + minKey, Max: "(" + maxKey + string(rune(losslessSeparator+1))}, nil
+	}
+	return LexRange{Min: "[" + minKey, Max: "[" + maxKey}, nil
+}
@@ -0,0 +1,118 @@
+package ansort
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// TestToNaturalScore verifies that scores never contradict natural order
+func TestToNaturalScore(t *testing.T) {
+	names := []string{"item1", "item2", "item9", "item10", "item100", "item1000"}
+	compact := WithKeyScheme(KeySchemeCompact)
+	for i := 1; i < len(names); i++ {
+		if ToNaturalScore(names[i-1], compact) >= ToNaturalScore(names[i], compact) {
+			t.Errorf("Score of %q should be below score of %q", names[i-1], names[i])
+		}
+	}
+
+	rng := rand.New(rand.NewSource(16))
+	options := []ExternalSortKeyOption{WithKeyScheme(KeySchemeOrdered), WithExternalCaseInsensitive()}
+	for i := 0; i < 5000; i++ {
+		a, b := randomKeyTestString(rng), randomKeyTestString(rng)
+		if Compare(a, b, WithCaseInsensitive()) > 0 {
+			a, b = b, a
+		}
+		scoreA, scoreB := ToNaturalScore(a, options...), ToNaturalScore(b, options...)
+		if scoreA > scoreB {
+			t.Fatalf("Score of %q (%v) above score of %q (%v)", a, scoreA, b, scoreB)
+		}
+
+		keyA, keyB := ToNaturalSortKey(a, options...), ToNaturalSortKey(b, options...)
+		prefix := func(key string) string {
+			if len(key) > NaturalScorePrecisionBytes {
+				return key[:NaturalScorePrecisionBytes]
+			}
+			return key
+		}
+		if prefix(keyA) != prefix(keyB) && scoreA == scoreB {
+			t.Fatalf("Keys %q and %q differ within the precision but have equal scores", keyA, keyB)
+		}
+	}
+}
+
+// lexMemberInRange emulates ZRANGEBYLEX bound checks for a member
+func lexMemberInRange(member string, r LexRange) bool {
+	above := func(bound string) bool {
+		if bound == "-" {
+			return true
+		}
+		if bound[0] == '[' {
+			return member >= bound[1:]
+		}
+		return member > bound[1:]
+	}
+	below := func(bound string) bool {
+		if bound == "+" {
+			return true
+		}
+		if bound[0] == '[' {
+			return member <= bound[1:]
+		}
+		return member < bound[1:]
+	}
+	return above(r.Min) && below(r.Max)
+}
+
+// TestNaturalLexRange verifies that lex ranges select the natural range
+func TestNaturalLexRange(t *testing.T) {
+	var names []string
+	for i := 0; i <= 30; i++ {
+		names = append(names, fmt.Sprintf("file%d", i), fmt.Sprintf("File%d", i), fmt.Sprintf("file%d.bak", i))
+	}
+	names = append(names, "", "file", "files", "file005")
+
+	configs := [][]ExternalSortKeyOption{
+		{WithKeyScheme(KeySchemeOrdered)},
+		{WithKeyScheme(KeySchemeOrdered), WithExternalCaseInsensitive(), WithLosslessKey()},
+		{WithKeyScheme(KeySchemeOrdered), WithExternalCaseInsensitive(), WithExternalCaseTieBreak(), WithKeyHeader()},
+	}
+	ranges := [][2]string{{"file5", "file20"}, {"File5", "file5"}, {"", "file1"}, {"file29", "files"}}
+
+	for _, options := range configs {
+		compareOptions := []Option{withConfig(buildExternalSortKeyConfig(options...).compareConfig())}
+		compareOptions = append(compareOptions, func(c *Config) { c.CaseTieBreak = false })
+
+		for _, bounds := range ranges {
+			r, err := NaturalLexRange(bounds[0], bounds[1], options...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, name := range names {
+				expected := Compare(bounds[0], name, compareOptions...) <= 0 && Compare(name, bounds[1], compareOptions...) <= 0
+				if got := lexMemberInRange(ToNaturalSortKey(name, options...), r); got != expected {
+					t.Errorf("Range %q..%q with %d options: member %q included = %v, want %v", bounds[0], bounds[1], len(options), name, got, expected)
+				}
+			}
+		}
+	}
+}
+
+// TestNaturalLexRangeValidation tests unsupported and invalid options
+func TestNaturalLexRangeValidation(t *testing.T) {
+	tests := [][]ExternalSortKeyOption{
+		{WithDescendingKey()},
+		{WithMaxKeyBytes(32)},
+		{WithMaxNumericLength(0)},
+	}
+	for _, options := range tests {
+		if _, err := NaturalLexRange("a", "b", options...); err == nil {
+			t.Errorf("Expected error for options %v", options)
+		}
+	}
+
+	r, err := NaturalLexRange("a1", "a2")
+	if err != nil || r.Min != "[a0000000001" || r.Max != "[a0000000002" {
+		t.Errorf("NaturalLexRange() = %+v, %v", r, err)
+	}
+}
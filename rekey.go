@@ -0,0 +1,315 @@
+package ansort
+
+import (
+	"context"
+	"sync"
+)
+
+// RekeyItem is a stored record whose sort key should be regenerated
+type RekeyItem struct {
+	// ID identifies the record to the caller, such as a primary key.
+	// It is passed through unchanged.
+	ID any
+	// Input is the original string the key was generated from
+	Input string
+	// OldKey is the currently stored key. When Input is empty and OldKey is
+	// not the key of an empty input, the input is recovered from OldKey,
+	// which requires the old configuration to be lossless.
+	OldKey string
+}
+
+// RekeyResult is the outcome of regenerating the key of one item
+type RekeyResult struct {
+	// Index is the position of the item in the input stream
+	Index int
+	// ID is the ID of the item
+	ID any
+	// Input is the original string, recovered from OldKey if necessary
+	Input string
+	// OldKey is the key stored before re-keying
+	OldKey string
+	// NewKey is the key generated with the new configuration
+	NewKey string
+	// Changed reports whether NewKey differs from OldKey
+	Changed bool
+	// Err is set when the item could not be re-keyed; NewKey is then empty
+	Err error
+}
+
+// RekeyProgress reports the progress of RekeySortKeys
+type RekeyProgress struct {
+	// Processed is the number of items re-keyed so far, including failures
+	Processed int
+	// Failed is the number of items that could not be re-keyed
+	Failed int
+}
+
+// RekeyConfig holds configuration options for RekeySortKeys
+type RekeyConfig struct {
+	// ChunkSize is the number of items processed together by a worker
+	// Default: 1000
+	ChunkSize int
+	// Workers is the number of chunks processed in parallel
+	// Default: 4
+	Workers int
+	// Progress is called after every chunk with the running totals.
+	// Calls are serialized. Default: nil (no progress reporting)
+	Progress func(RekeyProgress)
+}
+
+// RekeyOption is a functional option for configuring RekeySortKeys
+type RekeyOption func(*RekeyConfig)
+
+// WithRekeyChunkSize sets the number of items processed together by a worker
+func WithRekeyChunkSize(size int) RekeyOption {
+	return func(c *RekeyConfig) {
+		c.ChunkSize = size
+	}
+}
+
+// WithRekeyWorkers sets the number of chunks processed in parallel
+func WithRekeyWorkers(workers int) RekeyOption {
+	return func(c *RekeyConfig) {
+		c.Workers = workers
+	}
+}
+
+// WithRekeyProgress sets a callback that receives the running totals after every chunk
+func WithRekeyProgress(progress func(RekeyProgress)) RekeyOption {
+	return func(c *RekeyConfig) {
+		c.Progress = progress
+	}
+}
+
+// DefaultRekeyConfig returns a RekeyConfig with default settings
+func DefaultRekeyConfig() RekeyConfig {
+	return RekeyConfig{
+		ChunkSize: 1000,
+		Workers:   4,
+	}
+}
+
+// validateRekeyConfig validates the re-keying options
+// Returns an error if the configuration is invalid
+func validateRekeyConfig(config RekeyConfig) error {
+	if config.ChunkSize <= 0 {
+		return &ValidationError{
+			Field:   "ChunkSize",
+			Message: "must be greater than 0",
+		}
+	}
+	if config.Workers <= 0 {
+		return &ValidationError{
+			Field:   "Workers",
+			Message: "must be greater than 0",
+		}
+	}
+	return nil
+}
+
+// RekeySortKeys regenerates stored sort keys after a change of configuration,
+// such as a new padding length or case setting. Items are read from the items
+// channel, grouped into chunks and re-keyed in parallel; every item produces
+// exactly one result, with a per-item error if its input could not be
+// recovered. Results of different chunks may arrive out of order, so use
+// RekeyResult.Index or ID to match them to their items.
+//
+// Both configurations are validated before any work starts. The returned
+// channel is closed once the items channel is closed and drained, or when ctx
+// is cancelled.
+//
+// Example:
+//
+//	newConfig := oldConfig
+//	newConfig.MaxNumericLength = 20
+//	results, err := ansort.RekeySortKeys(ctx, oldConfig, newConfig, items,
+//		ansort.WithRekeyProgress(func(p ansort.RekeyProgress) {
+//			log.Printf("re-keyed %d items, %d failed", p.Processed, p.Failed)
+//		}))
+//	if err != nil {
+//		log.Fatal(err) // Invalid configuration, nothing was processed
+//	}
+//	for result := range results {
+//		if result.Err == nil && result.Changed {
+//			// UPDATE files SET sort_key = result.NewKey WHERE id = result.ID
+//		}
+//	}
+func RekeySortKeys(ctx context.Context, oldConfig, newConfig ExternalSortKeyConfig, items <-chan RekeyItem, options ...RekeyOption) (<-chan RekeyResult, error) {
+	if err := validateExternalSortKeyConfig(oldConfig); err != nil {
+		return nil, err
+	}
+	if err := validateExternalSortKeyConfig(newConfig); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		return nil, &ValidationError{
+			Field:   "items",
+			Message: "channel cannot be nil for RekeySortKeys",
+		}
+	}
+
+	config := DefaultRekeyConfig()
+	for _, option := range options {
+		option(&config)
+	}
+	if err := validateRekeyConfig(config); err != nil {
+		return nil, err
+	}
+
+	rekeyer := &sortKeyRekeyer{
+		oldConfig:  oldConfig,
+		newOptions: externalOptionsFor(newConfig),
+		config:     config,
+	}
+	chunks := make(chan []indexedRekeyItem, config.Workers)
+	results := make(chan RekeyResult, config.ChunkSize)
+
+	go rekeyer.readChunks(ctx, items, chunks)
+
+	var wg sync.WaitGroup
+	for i := 0; i < config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				if !rekeyer.processChunk(ctx, chunk, results) {
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results, nil
+}
+
+// indexedRekeyItem is an item with its position in the input stream
+type indexedRekeyItem struct {
+	index int
+	item  RekeyItem
+}
+
+// sortKeyRekeyer holds the shared state of a RekeySortKeys run
+type sortKeyRekeyer struct {
+	oldConfig  ExternalSortKeyConfig
+	newOptions []ExternalSortKeyOption
+	config     RekeyConfig
+
+	mu       sync.Mutex
+	progress RekeyProgress
+}
+
+// readChunks groups items into chunks until the items channel is closed or
+// ctx is cancelled, then closes chunks
+func (r *sortKeyRekeyer) readChunks(ctx context.Context, items <-chan RekeyItem, chunks chan<- []indexedRekeyItem) {
+	defer close(chunks)
+
+	index := 0
+	chunk := make([]indexedRekeyItem, 0, r.config.ChunkSize)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item, ok := <-items:
+			if !ok {
+				if len(chunk) > 0 {
+					select {
+					case chunks <- chunk:
+					case <-ctx.Done():
+					}
+				}
+				return
+			}
+
+			chunk = append(chunk, indexedRekeyItem{index: index, item: item})
+			index++
+			if len(chunk) == r.config.ChunkSize {
+				select {
+				case chunks <- chunk:
+				case <-ctx.Done():
+					return
+				}
+				chunk = make([]indexedRekeyItem, 0, r.config.ChunkSize)
+			}
+		}
+	}
+}
+
+// processChunk re-keys a chunk and sends its results. It returns false if
+// ctx was cancelled before all results were sent.
+func (r *sortKeyRekeyer) processChunk(ctx context.Context, chunk []indexedRekeyItem, results chan<- RekeyResult) bool {
+	chunkResults := make([]RekeyResult, len(chunk))
+	inputs := make([]string, 0, len(chunk))
+	for i, indexed := range chunk {
+		input, err := rekeyInput(indexed.item, r.oldConfig)
+		chunkResults[i] = RekeyResult{
+			Index:  indexed.index,
+			ID:     indexed.item.ID,
+			Input:  input,
+			OldKey: indexed.item.OldKey,
+			Err:    err,
+		}
+		if err == nil {
+			inputs = append(inputs, input)
+		}
+	}
+
+	// The target configuration was validated up front, so this only fails
+	// if validation rules change
+	keys, err := ToNaturalSortKeysValidated(inputs, r.newOptions...)
+	failed := 0
+	for i := range chunkResults {
+		result := &chunkResults[i]
+		switch {
+		case result.Err != nil:
+		case err != nil:
+			result.Err = err
+		default:
+			result.NewKey = keys[0]
+			result.Changed = result.NewKey != result.OldKey
+			keys = keys[1:]
+		}
+		if result.Err != nil {
+			failed++
+		}
+	}
+
+	for _, result := range chunkResults {
+		select {
+		case results <- result:
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	r.reportProgress(len(chunk), failed)
+	return true
+}
+
+// reportProgress adds a processed chunk to the totals and notifies the callback
+func (r *sortKeyRekeyer) reportProgress(processed, failed int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.progress.Processed += processed
+	r.progress.Failed += failed
+	if r.config.Progress != nil {
+		r.config.Progress(r.progress)
+	}
+}
+
+// rekeyInput returns the original input of an item, recovering it from the
+// old key when the item has no input
+func rekeyInput(item RekeyItem, oldConfig ExternalSortKeyConfig) (string, error) {
+	if item.Input != "" || item.OldKey == "" || item.OldKey == generateSortKeyWithConfig("", oldConfig) {
+		return item.Input, nil
+	}
+	if !oldConfig.keyFormat().Lossless {
+		return "", ErrInvalidSortKey
+	}
+	return FromNaturalSortKey(item.OldKey)
+}
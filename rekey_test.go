@@ -0,0 +1,140 @@
+package ansort
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// rekeyItems returns a closed channel delivering the items
+func rekeyItems(items []RekeyItem) <-chan RekeyItem {
+	ch := make(chan RekeyItem, len(items))
+	for _, item := range items {
+		ch <- item
+	}
+	close(ch)
+	return ch
+}
+
+// TestRekeySortKeys tests re-keying after a change of padding length and case
+func TestRekeySortKeys(t *testing.T) {
+	oldConfig := buildExternalSortKeyConfig(WithMaxNumericLength(4), WithLosslessKey())
+	newConfig := buildExternalSortKeyConfig(WithMaxNumericLength(12), WithExternalCaseInsensitive())
+
+	var items []RekeyItem
+	for i := 0; i < 250; i++ {
+		input := fmt.Sprintf("File%d.txt", i)
+		item := RekeyItem{ID: i, OldKey: ToNaturalSortKey(input, WithMaxNumericLength(4), WithLosslessKey())}
+		// Every other item relies on recovering the input from its lossless key
+		if i%2 == 0 {
+			item.Input = input
+		}
+		items = append(items, item)
+	}
+	items = append(items, RekeyItem{ID: "bad", OldKey: "not a lossless key"})
+
+	var mu sync.Mutex
+	var last RekeyProgress
+	calls := 0
+	results, err := RekeySortKeys(context.Background(), oldConfig, newConfig, rekeyItems(items),
+		WithRekeyChunkSize(16), WithRekeyWorkers(3),
+		WithRekeyProgress(func(p RekeyProgress) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			last = p
+		}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	seen := make(map[int]bool)
+	for result := range results {
+		if seen[result.Index] {
+			t.Errorf("Duplicate result for index %d", result.Index)
+		}
+		seen[result.Index] = true
+
+		if result.ID == "bad" {
+			if result.Err != ErrInvalidSortKey || result.NewKey != "" {
+				t.Errorf("Expected ErrInvalidSortKey for bad item, got %+v", result)
+			}
+			continue
+		}
+		if result.ID != result.Index {
+			t.Errorf("Result ID %v does not match index %d", result.ID, result.Index)
+		}
+		expected := fmt.Sprintf("File%d.txt", result.Index)
+		if result.Err != nil || result.Input != expected {
+			t.Errorf("Result %d = %+v, want input %q", result.Index, result, expected)
+		}
+		if result.NewKey != ToNaturalSortKey(expected, WithMaxNumericLength(12), WithExternalCaseInsensitive()) || !result.Changed {
+			t.Errorf("Result %d has wrong new key %q", result.Index, result.NewKey)
+		}
+	}
+
+	if len(seen) != len(items) {
+		t.Errorf("Got %d results, want %d", len(seen), len(items))
+	}
+	if calls != 16 || last.Processed != len(items) || last.Failed != 1 {
+		t.Errorf("Progress after %d calls = %+v, want 16 calls with %d processed and 1 failed", calls, last, len(items))
+	}
+}
+
+// TestRekeySortKeysValidation verifies that invalid configurations fail before any work
+func TestRekeySortKeysValidation(t *testing.T) {
+	valid := DefaultExternalSortKeyConfig()
+	invalid := valid
+	invalid.MaxNumericLength = 100
+
+	items := make(chan RekeyItem)
+	tests := []struct {
+		name      string
+		oldConfig ExternalSortKeyConfig
+		newConfig ExternalSortKeyConfig
+		items     chan RekeyItem
+		options   []RekeyOption
+		field     string
+	}{
+		{"invalid target", valid, invalid, items, nil, "MaxNumericLength"},
+		{"invalid source", invalid, valid, items, nil, "MaxNumericLength"},
+		{"nil items", valid, valid, nil, nil, "items"},
+		{"chunk size", valid, valid, items, []RekeyOption{WithRekeyChunkSize(0)}, "ChunkSize"},
+		{"workers", valid, valid, items, []RekeyOption{WithRekeyWorkers(-1)}, "Workers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := RekeySortKeys(context.Background(), tt.oldConfig, tt.newConfig, tt.items, tt.options...)
+			if results != nil {
+				t.Error("Expected no results channel")
+			}
+			valErr, ok := err.(*ValidationError)
+			if !ok || valErr.Field != tt.field {
+				t.Errorf("Expected ValidationError for %s, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+// TestRekeySortKeysCancel verifies that cancellation closes the results channel
+func TestRekeySortKeysCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	items := make(chan RekeyItem)
+	config := DefaultExternalSortKeyConfig()
+
+	results, err := RekeySortKeys(ctx, config, config, items, WithRekeyChunkSize(1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	items <- RekeyItem{Input: "file1"}
+	result := <-results
+	if result.NewKey != "file0000000001" || result.Changed != true {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	cancel()
+	for range results {
+	}
+}
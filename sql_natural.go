@@ -0,0 +1,167 @@
+package ansort
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// NaturalString is a string that can be stored in and read from a database
+// either as itself or as its external sort key. It implements driver.Valuer
+// and sql.Scanner, so it can be passed directly as a query argument and as
+// a rows.Scan destination.
+//
+// The zero value stores the original string with default sort key options.
+type NaturalString struct {
+	// String is the original display value
+	String string
+
+	config *ExternalSortKeyConfig
+	asKey  bool
+}
+
+// NewNaturalString creates a NaturalString whose sort key is generated with
+// the given options. Its Value is the original string; use SortKey for a
+// value that stores the sort key instead.
+//
+// Example:
+//
+//	name := ansort.NewNaturalString("File10.txt", ansort.WithLosslessKey())
+//	_, err := db.Exec("INSERT INTO files (name, name_key) VALUES (?, ?)", name, name.SortKey())
+func NewNaturalString(s string, options ...ExternalSortKeyOption) NaturalString {
+	config := buildExternalSortKeyConfig(options...)
+	return NaturalString{String: s, config: &config}
+}
+
+// SortKey returns a copy whose Value is the sort key, and whose Scan decodes
+// a stored key back into the original string (requires WithLosslessKey)
+func (n NaturalString) SortKey() NaturalString {
+	n.asKey = true
+	return n
+}
+
+// Original returns a copy whose Value is the original string
+func (n NaturalString) Original() NaturalString {
+	n.asKey = false
+	return n
+}
+
+// Key returns the sort key of the string.
+// Returns an error if the sort key options are invalid.
+func (n NaturalString) Key() (string, error) {
+	config := n.keyConfig()
+	if err := validateExternalSortKeyConfig(config); err != nil {
+		return "", err
+	}
+	return generateSortKeyWithConfig(n.String, config), nil
+}
+
+// Value implements driver.Valuer. It returns the sort key for values created
+// with SortKey and the original string otherwise.
+func (n NaturalString) Value() (driver.Value, error) {
+	if n.asKey {
+		return n.Key()
+	}
+	return n.String, nil
+}
+
+// Scan implements sql.Scanner. It reads the original string, or decodes it
+// from a lossless sort key for values created with SortKey. NULL scans as
+// an empty string.
+//
+// Returns ErrInvalidSortKey when scanning a key without WithLosslessKey, or
+// when the stored key is not a valid lossless key.
+func (n *NaturalString) Scan(src any) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("ansort: cannot scan %T into NaturalString", src)
+	}
+
+	if n.asKey {
+		if !n.keyConfig().keyFormat().Lossless {
+			return ErrInvalidSortKey
+		}
+		original, err := FromNaturalSortKey(text)
+		if err != nil {
+			return err
+		}
+		text = original
+	}
+	n.String = text
+	return nil
+}
+
+// keyConfig returns the sort key configuration, defaulting for the zero value
+func (n NaturalString) keyConfig() ExternalSortKeyConfig {
+	if n.config == nil {
+		return DefaultExternalSortKeyConfig()
+	}
+	return *n.config
+}
+
+// Execer is implemented by *sql.DB, *sql.Tx and *sql.Conn
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// NaturalInsertQuery builds a single INSERT statement that writes each value
+// and its sort key into valueColumn and keyColumn, with '?' placeholders.
+// Keys are generated with ToNaturalSortKeysValidated. Table and column names
+// are inserted verbatim and must be trusted.
+//
+// Returns an error if values is nil or empty, or the options are invalid.
+//
+// Example:
+//
+//	query, args, err := ansort.NaturalInsertQuery("files", "name", "name_key",
+//		[]string{"file10", "file2"})
+//	// query: "INSERT INTO files (name, name_key) VALUES (?, ?), (?, ?)"
+//	// args:  "file10", "file0000000010", "file2", "file0000000002"
+func NaturalInsertQuery(table, valueColumn, keyColumn string, values []string, options ...ExternalSortKeyOption) (string, []any, error) {
+	keys, err := ToNaturalSortKeysValidated(values, options...)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(values) == 0 {
+		return "", nil, &ValidationError{
+			Field:   "values",
+			Message: "must contain at least one value",
+		}
+	}
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO " + table + " (" + valueColumn + ", " + keyColumn + ") VALUES ")
+	args := make([]any, 0, len(values)*2)
+	for i, value := range values {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(?, ?)")
+		args = append(args, value, keys[i])
+	}
+	return query.String(), args, nil
+}
+
+// ExecNaturalInsert writes values and their sort keys into valueColumn and
+// keyColumn with a single INSERT built by NaturalInsertQuery. Drivers that
+// need numbered placeholders can rebind the query from NaturalInsertQuery.
+//
+// Example:
+//
+//	_, err := ansort.ExecNaturalInsert(ctx, db, "files", "name", "name_key",
+//		names, ansort.WithKeyScheme(ansort.KeySchemeOrdered))
+func ExecNaturalInsert(ctx context.Context, db Execer, table, valueColumn, keyColumn string, values []string, options ...ExternalSortKeyOption) (sql.Result, error) {
+	query, args, err := NaturalInsertQuery(table, valueColumn, keyColumn, values, options...)
+	if err != nil {
+		return nil, err
+	}
+	return db.ExecContext(ctx, query, args...)
+}
@@ -0,0 +1,239 @@
+package ansort
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSQLDriver is a minimal database/sql driver that stores the arguments of
+// every INSERT as rows of two columns and returns them from any query
+type fakeSQLDriver struct {
+	mu      sync.Mutex
+	queries []string
+	rows    [][]driver.Value
+}
+
+var fakeDriver = &fakeSQLDriver{}
+
+func init() {
+	sql.Register("ansortfake", fakeDriver)
+}
+
+func (d *fakeSQLDriver) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+func (d *fakeSQLDriver) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = nil
+	d.rows = nil
+}
+
+type fakeConn struct{ d *fakeSQLDriver }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.d, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	d     *fakeSQLDriver
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return strings.Count(s.query, "?") }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.queries = append(s.d.queries, s.query)
+	for i := 0; i+1 < len(args); i += 2 {
+		s.d.rows = append(s.d.rows, []driver.Value{args[i], args[i+1]})
+	}
+	return driver.RowsAffected(len(args) / 2), nil
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	rows := make([][]driver.Value, len(s.d.rows))
+	for i, row := range s.d.rows {
+		// Return keys as []byte, as many drivers do
+		rows[i] = []driver.Value{row[0], []byte(row[1].(string))}
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct{ rows [][]driver.Value }
+
+func (r *fakeRows) Columns() []string { return []string{"value", "sort_key"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// openFakeDB opens a database backed by the fake driver with no stored rows
+func openFakeDB(t *testing.T) *sql.DB {
+	t.Helper()
+	fakeDriver.reset()
+	db, err := sql.Open("ansortfake", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// TestNaturalStringValue tests the values emitted for original and key modes
+func TestNaturalStringValue(t *testing.T) {
+	options := []ExternalSortKeyOption{WithKeyScheme(KeySchemeOrdered), WithLosslessKey()}
+	name := NewNaturalString("File10.txt", options...)
+
+	value, err := name.Value()
+	if err != nil || value != "File10.txt" {
+		t.Errorf("Value() = %v, %v, want original", value, err)
+	}
+	value, err = name.SortKey().Value()
+	if want := ToNaturalSortKey("File10.txt", options...); err != nil || value != want {
+		t.Errorf("SortKey().Value() = %v, %v, want %q", value, err, want)
+	}
+	value, _ = name.SortKey().Original().Value()
+	if value != "File10.txt" {
+		t.Errorf("Original().Value() = %v, want original", value)
+	}
+
+	var zero NaturalString
+	zero.String = "item2"
+	if value, _ := zero.SortKey().Value(); value != ToNaturalSortKey("item2") {
+		t.Errorf("Zero value key = %v, want default key", value)
+	}
+
+	invalid := NewNaturalString("x", WithMaxNumericLength(0)).SortKey()
+	var validationErr *ValidationError
+	if _, err := invalid.Value(); !errors.As(err, &validationErr) {
+		t.Errorf("Invalid options: error = %v, want ValidationError", err)
+	}
+}
+
+// TestNaturalStringScan tests scanning originals and decoding lossless keys
+func TestNaturalStringScan(t *testing.T) {
+	var original NaturalString
+	for _, src := range []any{"file2", []byte("file2")} {
+		if err := original.Scan(src); err != nil || original.String != "file2" {
+			t.Errorf("Scan(%v) = %q, %v", src, original.String, err)
+		}
+	}
+	if err := original.Scan(nil); err != nil || original.String != "" {
+		t.Errorf("Scan(nil) = %q, %v, want empty", original.String, err)
+	}
+	if err := original.Scan(42); err == nil {
+		t.Error("Scan(int) should return an error")
+	}
+
+	key := NewNaturalString("", WithLosslessKey(), WithDescendingKey()).SortKey()
+	if err := key.Scan(ToNaturalSortKey("Ärger 7", WithLosslessKey(), WithDescendingKey())); err != nil || key.String != "Ärger 7" {
+		t.Errorf("Scan(lossless key) = %q, %v", key.String, err)
+	}
+
+	lossy := NaturalString{}.SortKey()
+	if err := lossy.Scan(ToNaturalSortKey("file2")); err != ErrInvalidSortKey {
+		t.Errorf("Scan without lossless key: error = %v, want ErrInvalidSortKey", err)
+	}
+}
+
+// TestNaturalInsertQuery tests the generated multi-row INSERT
+func TestNaturalInsertQuery(t *testing.T) {
+	query, args, err := NaturalInsertQuery("files", "name", "name_key", []string{"file10", "file2"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := "INSERT INTO files (name, name_key) VALUES (?, ?), (?, ?)"; query != want {
+		t.Errorf("Query = %q, want %q", query, want)
+	}
+	want := []any{"file10", "file0000000010", "file2", "file0000000002"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("Args = %q, want %q", args, want)
+	}
+
+	var validationErr *ValidationError
+	for _, values := range [][]string{nil, {}} {
+		if _, _, err := NaturalInsertQuery("t", "v", "k", values); !errors.As(err, &validationErr) {
+			t.Errorf("NaturalInsertQuery(%v) error = %v, want ValidationError", values, err)
+		}
+	}
+	if _, _, err := NaturalInsertQuery("t", "v", "k", []string{"a"}, WithKeyScheme(KeyScheme(9))); !errors.As(err, &validationErr) {
+		t.Errorf("Invalid options: error = %v, want ValidationError", err)
+	}
+}
+
+// TestNaturalStringRoundTrip tests writing and reading both columns through database/sql
+func TestNaturalStringRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db := openFakeDB(t)
+	options := []ExternalSortKeyOption{WithKeyScheme(KeySchemeOrdered), WithLosslessKey(), WithKeyHeader()}
+	values := []string{"file10.txt", "File2.txt", "", "ünïcode 3"}
+
+	result, err := ExecNaturalInsert(ctx, db, "files", "name", "name_key", values, options...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected != int64(len(values)) {
+		t.Errorf("RowsAffected = %d, want %d", affected, len(values))
+	}
+	if len(fakeDriver.queries) != 1 {
+		t.Errorf("Executed %d statements, want 1", len(fakeDriver.queries))
+	}
+
+	// Values passed as arguments go through driver.Valuer
+	name := NewNaturalString("item7", options...)
+	if _, err := db.ExecContext(ctx, "INSERT INTO files (name, name_key) VALUES (?, ?)", name, name.SortKey()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	values = append(values, "item7")
+
+	keys, err := ToNaturalSortKeysValidated(values, options...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT name, name_key FROM files")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer rows.Close()
+
+	i := 0
+	for rows.Next() {
+		var value NaturalString
+		decoded := NewNaturalString("", options...).SortKey()
+		var rawKey string
+		if err := rows.Scan(&value, &decoded); err != nil {
+			t.Fatalf("Scan error: %v", err)
+		}
+		if value.String != values[i] || decoded.String != values[i] {
+			t.Errorf("Row %d = %q, %q, want %q", i, value.String, decoded.String, values[i])
+		}
+		if rawKey, err = decoded.Key(); err != nil || rawKey != keys[i] {
+			t.Errorf("Row %d key = %q, %v, want %q", i, rawKey, err, keys[i])
+		}
+		i++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Rows error: %v", err)
+	}
+	if i != len(values) {
+		t.Errorf("Read %d rows, want %d", i, len(values))
+	}
+}
@@ -0,0 +1,309 @@
+package ansort
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// SortStrategy selects how SortStrings and the other optimized sorts
+// tokenize and compare strings. Every strategy produces the same order.
+type SortStrategy int
+
+const (
+	// StrategyAuto chooses between StrategyLegacy and StrategyCached from a
+	// sample of the data, as described by PlanSort. This is the default.
+	StrategyAuto SortStrategy = iota
+	// StrategyLegacy compares pairs as Compare does, tokenizing on every
+	// comparison; only strings of 10 bytes or more go through the cache
+	StrategyLegacy
+	// StrategyCached tokenizes through the shared token cache on every comparison
+	StrategyCached
+	// StrategyPooled tokenizes each string once into a private cache for the
+	// sort, using pooled token buffers, and leaves the shared cache untouched
+	StrategyPooled
+	// StrategyPrecomputedKeys tokenizes every string once before sorting and
+	// sorts the strings together with their tokens
+	StrategyPrecomputedKeys
+	// StrategyParallel precomputes tokens and sorts chunks of the data on
+	// all available CPUs, then merges them. Small inputs use a single chunk.
+	StrategyParallel
+
+	// sortStrategyCount is the number of strategies
+	sortStrategyCount
+)
+
+// String returns the name of the sort strategy
+func (s SortStrategy) String() string {
+	switch s {
+	case StrategyAuto:
+		return "auto"
+	case StrategyLegacy:
+		return "legacy"
+	case StrategyCached:
+		return "cached"
+	case StrategyPooled:
+		return "pooled"
+	case StrategyPrecomputedKeys:
+		return "precomputed-keys"
+	case StrategyParallel:
+		return "parallel"
+	default:
+		return "unknown"
+	}
+}
+
+// WithStrategy pins the strategy used by SortStrings, SortStringsOptimized
+// and Collator.Sort instead of choosing one automatically. An explicit
+// strategy is used even when caching is disabled. Comparison functions such
+// as Compare ignore it.
+//
+// Example:
+//
+//	// Latency-sensitive path: avoid the shared cache and its locks
+//	ansort.SortStrings(names, ansort.WithStrategy(ansort.StrategyPrecomputedKeys))
+func WithStrategy(strategy SortStrategy) Option {
+	return func(c *Config) {
+		c.Strategy = strategy
+	}
+}
+
+// validateSortStrategy validates the sort strategy
+func validateSortStrategy(strategy SortStrategy) error {
+	if strategy < StrategyAuto || strategy >= sortStrategyCount {
+		return &ValidationError{
+			Field:   "Strategy",
+			Message: "must be one of StrategyAuto, StrategyLegacy, StrategyCached, StrategyPooled, StrategyPrecomputedKeys or StrategyParallel",
+		}
+	}
+	return nil
+}
+
+// Thresholds of automatic strategy selection
+const (
+	// autoCachingMinItems is the size from which data is always sorted with the cache
+	autoCachingMinItems = 300
+	// autoSampleSize is the number of leading strings sampled in smaller data
+	autoSampleSize = 50
+	// autoDuplicateRatio is the share of repeated sampled strings above which caching pays off
+	autoDuplicateRatio = 0.2
+	// autoLongString is the average sampled length, in bytes, above which caching pays off
+	autoLongString = 50
+)
+
+// SortPlan describes how a sort is performed and the statistics that drove
+// the choice of strategy
+type SortPlan struct {
+	// Strategy is the strategy the sort uses; never StrategyAuto
+	Strategy SortStrategy
+	// Requested is the strategy set with WithStrategy, or StrategyAuto
+	Requested SortStrategy
+	// Reason explains why the strategy was chosen
+	Reason string
+	// Items is the number of strings to sort
+	Items int
+	// SampleSize is the number of leading strings sampled, or 0 if automatic
+	// selection did not need a sample
+	SampleSize int
+	// DuplicateRatio is the share of sampled strings that repeat an earlier one
+	DuplicateRatio float64
+	// AverageLength is the mean length of the sampled strings in bytes, rounded down
+	AverageLength int
+}
+
+// PlanSort reports the strategy SortStrings would use for data with the
+// given options, and the sampled statistics behind an automatic choice.
+// It does not modify data.
+//
+// Returns an error if the configuration is invalid.
+//
+// Example:
+//
+//	plan, err := ansort.PlanSort(names)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	log.Printf("sorting %d items with %s: %s (%.0f%% duplicates, %d bytes on average)",
+//		plan.Items, plan.Strategy, plan.Reason, plan.DuplicateRatio*100, plan.AverageLength)
+func PlanSort(data []string, options ...Option) (SortPlan, error) {
+	config := buildConfig(options...)
+	if err := validateConfig(config); err != nil {
+		return SortPlan{}, err
+	}
+	return defaultCollator.planSort(data, config), nil
+}
+
+// PlanSort reports the strategy Sort would use for data, like PlanSort
+func (c *Collator) PlanSort(data []string) SortPlan {
+	return c.planSort(data, c.config)
+}
+
+// planSort chooses the strategy for sorting data with the given configuration
+func (c *Collator) planSort(data []string, config Config) SortPlan {
+	switch {
+	case config.Strategy > StrategyAuto && config.Strategy < sortStrategyCount:
+		return SortPlan{
+			Strategy:  config.Strategy,
+			Requested: config.Strategy,
+			Reason:    "requested with WithStrategy",
+			Items:     len(data),
+		}
+	case c.cacheDisabled.Load():
+		return SortPlan{Strategy: StrategyLegacy, Reason: "caching is disabled", Items: len(data)}
+	default:
+		return planAutoSort(data)
+	}
+}
+
+// planAutoSort chooses between the legacy and cached strategies based on
+// dataset characteristics
+func planAutoSort(data []string) SortPlan {
+	plan := SortPlan{Items: len(data)}
+
+	// For very large datasets, caching benefits depend on repeated operations,
+	// but the cache is still worth it at this size
+	if len(data) >= autoCachingMinItems {
+		plan.Strategy, plan.Reason = StrategyCached, "300 or more items"
+		return plan
+	}
+	if len(data) <= 1 {
+		plan.Strategy, plan.Reason = StrategyLegacy, "fewer than 2 items"
+		return plan
+	}
+
+	// For small to medium datasets, cache overhead often outweighs benefits
+	// unless there are patterns that benefit from caching. Sample up to 50
+	// strings to avoid expensive analysis.
+	plan.SampleSize = min(len(data), autoSampleSize)
+	seen := make(map[string]bool, plan.SampleSize)
+	duplicates, totalLength := 0, 0
+	for _, s := range data[:plan.SampleSize] {
+		if seen[s] {
+			duplicates++
+		}
+		seen[s] = true
+		totalLength += len(s)
+	}
+	plan.DuplicateRatio = float64(duplicates) / float64(plan.SampleSize)
+	plan.AverageLength = totalLength / plan.SampleSize
+
+	switch {
+	case plan.DuplicateRatio > autoDuplicateRatio:
+		plan.Strategy, plan.Reason = StrategyCached, "more than 20% of sampled strings are duplicates"
+	case plan.AverageLength > autoLongString:
+		plan.Strategy, plan.Reason = StrategyCached, "sampled strings average more than 50 bytes"
+	default:
+		// For smaller datasets with typical strings, legacy is often faster due to less overhead
+		plan.Strategy, plan.Reason = StrategyLegacy, "fewer than 300 short, mostly distinct strings"
+	}
+	return plan
+}
+
+// tokenizedString is a string with its precomputed tokenization
+type tokenizedString struct {
+	s      string
+	tokens []Token
+}
+
+// tokenizedSorter implements sort.Interface for strings with precomputed tokens
+type tokenizedSorter struct {
+	items  []tokenizedString
+	config Config
+}
+
+// Len implements sort.Interface
+func (s tokenizedSorter) Len() int {
+	return len(s.items)
+}
+
+// Less implements sort.Interface
+func (s tokenizedSorter) Less(i, j int) bool {
+	return compareTokenized(s.items[i], s.items[j], s.config) < 0
+}
+
+// Swap implements sort.Interface
+func (s tokenizedSorter) Swap(i, j int) {
+	s.items[i], s.items[j] = s.items[j], s.items[i]
+}
+
+// compareTokenized compares two strings using their precomputed tokens
+func compareTokenized(a, b tokenizedString, config Config) int {
+	if a.s == b.s {
+		return 0
+	}
+	return compareParsedStrings(a.s, b.s, a.tokens, b.tokens, config)
+}
+
+// parallelMinChunk is the smallest number of items StrategyParallel gives a CPU
+const parallelMinChunk = 4096
+
+// sortPrecomputed tokenizes every string once, on up to workers goroutines,
+// sorts chunks of one per worker concurrently and merges them
+func (c *Collator) sortPrecomputed(data []string, config Config, workers int) {
+	items := make([]tokenizedString, len(data))
+	chunk := parallelChunks(len(data), workers, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			items[i] = tokenizedString{s: data[i], tokens: c.parse(data[i])}
+		}
+		sort.Sort(tokenizedSorter{items: items[lo:hi], config: config})
+	})
+
+	// Merge neighbouring sorted runs until one remains
+	buffer := make([]tokenizedString, len(items))
+	for width := chunk; width < len(items); width *= 2 {
+		var wg sync.WaitGroup
+		for lo := 0; lo < len(items); lo += 2 * width {
+			mid, hi := min(lo+width, len(items)), min(lo+2*width, len(items))
+			wg.Add(1)
+			go func(dst, a, b []tokenizedString) {
+				defer wg.Done()
+				mergeTokenized(dst, a, b, config)
+			}(buffer[lo:hi], items[lo:mid], items[mid:hi])
+		}
+		wg.Wait()
+		items, buffer = buffer, items
+	}
+
+	for i := range items {
+		data[i] = items[i].s
+	}
+}
+
+// mergeTokenized merges two sorted runs into dst, which holds both
+func mergeTokenized(dst, a, b []tokenizedString, config Config) {
+	i, j := 0, 0
+	for k := range dst {
+		if j == len(b) || (i < len(a) && compareTokenized(a[i], b[j], config) <= 0) {
+			dst[k] = a[i]
+			i++
+		} else {
+			dst[k] = b[j]
+			j++
+		}
+	}
+}
+
+// parallelChunks splits [0, n) into at most workers contiguous chunks of
+// equal size, runs fn on each concurrently and returns the chunk size
+func parallelChunks(n, workers int, fn func(lo, hi int)) int {
+	if workers <= 1 || n <= 1 {
+		fn(0, n)
+		return max(n, 1)
+	}
+	size := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += size {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			fn(lo, hi)
+		}(lo, min(lo+size, n))
+	}
+	wg.Wait()
+	return size
+}
+
+// parallelWorkers returns the number of CPUs StrategyParallel uses for n items
+func parallelWorkers(n int) int {
+	return max(1, min(runtime.GOMAXPROCS(0), n/parallelMinChunk))
+}
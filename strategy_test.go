@@ -0,0 +1,199 @@
+package ansort
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// allStrategies lists every sort strategy, including automatic selection
+var allStrategies = []SortStrategy{
+	StrategyAuto, StrategyLegacy, StrategyCached, StrategyPooled, StrategyPrecomputedKeys, StrategyParallel,
+}
+
+// strategyDataset returns n random strings mixing text, numbers, case and Unicode
+func strategyDataset(n int, seed int64) []string {
+	rng := rand.New(rand.NewSource(seed))
+	prefixes := []string{"file", "File", "doc", "v", "Ärger", "", "item_", "x-"}
+	data := make([]string, n)
+	for i := range data {
+		data[i] = fmt.Sprintf("%s%d.%d%s", prefixes[rng.Intn(len(prefixes))], rng.Intn(200), rng.Intn(20),
+			strings.Repeat("z", rng.Intn(3)))
+	}
+	return data
+}
+
+// TestSortStrategiesAgree tests that every strategy produces the legacy order
+func TestSortStrategiesAgree(t *testing.T) {
+	datasets := map[string][]string{
+		"small":      {"file10", "file2", "File1", "file1", "a", ""},
+		"duplicates": append(strategyDataset(40, 1), strategyDataset(40, 1)...),
+		"large":      strategyDataset(3000, 2),
+	}
+	optionSets := [][]Option{
+		nil,
+		{WithCaseInsensitive(), WithCaseTieBreak()},
+		{WithDecimalNumbers('.'), WithSignedNumbers(SignStandalone)},
+	}
+
+	for name, dataset := range datasets {
+		for i, options := range optionSets {
+			expected := append([]string(nil), dataset...)
+			SortStringsLegacy(expected, options...)
+
+			for _, strategy := range allStrategies {
+				data := append([]string(nil), dataset...)
+				SortStrings(data, append(options, WithStrategy(strategy))...)
+				if !reflect.DeepEqual(data, expected) {
+					t.Errorf("%s, options %d, %v: order differs from legacy", name, i, strategy)
+				}
+			}
+		}
+	}
+}
+
+// TestSortPrecomputedMerges tests chunked sorting and merging with several workers
+func TestSortPrecomputedMerges(t *testing.T) {
+	c, _ := NewCollator()
+	dataset := strategyDataset(1001, 3)
+	expected := append([]string(nil), dataset...)
+	SortStringsLegacy(expected)
+
+	for workers := 1; workers <= 9; workers++ {
+		data := append([]string(nil), dataset...)
+		c.sortPrecomputed(data, c.config, workers)
+		if !reflect.DeepEqual(data, expected) {
+			t.Errorf("%d workers: order differs from legacy", workers)
+		}
+	}
+
+	short := []string{"b", "a"}
+	c.sortPrecomputed(short, c.config, 8)
+	if short[0] != "a" {
+		t.Errorf("Two items with 8 workers: got %v", short)
+	}
+}
+
+// TestPlanSort tests strategy selection and the reported statistics
+func TestPlanSort(t *testing.T) {
+	long := strings.Repeat("a_long_name_", 5)
+	distinct := make([]string, 100)
+	for i := range distinct {
+		distinct[i] = fmt.Sprintf("f%d", i)
+	}
+	duplicates := make([]string, 100)
+	for i := range duplicates {
+		duplicates[i] = fmt.Sprintf("f%d", i%10)
+	}
+
+	tests := []struct {
+		name       string
+		data       []string
+		options    []Option
+		strategy   SortStrategy
+		sampleSize int
+	}{
+		{"empty", nil, nil, StrategyLegacy, 0},
+		{"small distinct", distinct, nil, StrategyLegacy, 50},
+		{"duplicates", duplicates, nil, StrategyCached, 50},
+		{"long strings", []string{long + "1", long + "2"}, nil, StrategyCached, 2},
+		{"large", strategyDataset(300, 5), nil, StrategyCached, 0},
+		{"pinned", distinct, []Option{WithStrategy(StrategyParallel)}, StrategyParallel, 0},
+	}
+	for _, tt := range tests {
+		before := append([]string(nil), tt.data...)
+		plan, err := PlanSort(tt.data, tt.options...)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if plan.Strategy != tt.strategy || plan.SampleSize != tt.sampleSize || plan.Items != len(tt.data) || plan.Reason == "" {
+			t.Errorf("%s: plan = %+v", tt.name, plan)
+		}
+		if !reflect.DeepEqual(before, tt.data) {
+			t.Errorf("%s: PlanSort modified the data", tt.name)
+		}
+	}
+
+	plan, _ := PlanSort(duplicates)
+	if plan.DuplicateRatio != 0.8 || plan.AverageLength != 2 || plan.Requested != StrategyAuto {
+		t.Errorf("Duplicates plan = %+v, want 80%% duplicates of length 2", plan)
+	}
+	plan, _ = PlanSort(distinct, WithStrategy(StrategyPooled))
+	if plan.Requested != StrategyPooled {
+		t.Errorf("Requested = %v, want pooled", plan.Requested)
+	}
+
+	var validationErr *ValidationError
+	if _, err := PlanSort(distinct, WithStrategy(SortStrategy(42))); !errors.As(err, &validationErr) || validationErr.Field != "Strategy" {
+		t.Errorf("Invalid strategy: error = %v, want ValidationError", err)
+	}
+}
+
+// TestCollatorPlanSort tests strategy selection of collators
+func TestCollatorPlanSort(t *testing.T) {
+	large := strategyDataset(500, 6)
+	c, _ := NewCollator(WithCollatorCacheDisabled())
+	if plan := c.PlanSort(large); plan.Strategy != StrategyLegacy || plan.Reason != "caching is disabled" {
+		t.Errorf("Plan with caching disabled = %+v", plan)
+	}
+
+	pinned, _ := NewCollator(WithCollatorCacheDisabled(), WithCollatorCompareOptions(WithStrategy(StrategyCached)))
+	if plan := pinned.PlanSort(large); plan.Strategy != StrategyCached {
+		t.Errorf("Pinned plan = %+v, want cached even with caching disabled", plan)
+	}
+
+	for _, strategy := range allStrategies[1:] {
+		c, _ := NewCollator(WithCollatorCompareOptions(WithStrategy(strategy)))
+		c.Sort(append([]string(nil), large...))
+		if sorts := c.Metrics().Sorts; sorts[strategy.String()] != 1 {
+			t.Errorf("%v: sorts = %v", strategy, sorts)
+		}
+	}
+
+	if _, err := NewCollator(WithCollatorCompareOptions(WithStrategy(-1))); err == nil {
+		t.Error("NewCollator should reject an invalid strategy")
+	}
+}
+
+// TestPooledStrategyLeavesSharedCache tests that the pooled strategy uses a private cache
+func TestPooledStrategyLeavesSharedCache(t *testing.T) {
+	c, _ := NewCollator(WithCollatorCompareOptions(WithStrategy(StrategyPooled)))
+	c.Sort(strategyDataset(500, 7))
+	if stats := c.Stats(); stats.CacheSize != 0 || stats.Misses == 0 {
+		t.Errorf("Stats = %+v, want misses but no shared cache entries", stats)
+	}
+}
+
+// TestSortStrategyString tests strategy names
+func TestSortStrategyString(t *testing.T) {
+	names := []string{"auto", "legacy", "cached", "pooled", "precomputed-keys", "parallel"}
+	for i, strategy := range allStrategies {
+		if got := strategy.String(); got != names[i] {
+			t.Errorf("String() = %q, want %q", got, names[i])
+		}
+	}
+	if got := SortStrategy(42).String(); got != "unknown" {
+		t.Errorf("String() = %q, want unknown", got)
+	}
+}
+
+// BenchmarkSortStrategies compares the strategies on the same data
+func BenchmarkSortStrategies(b *testing.B) {
+	for _, size := range []int{100, 10000} {
+		dataset := strategyDataset(size, 1)
+		for _, strategy := range allStrategies {
+			b.Run(fmt.Sprintf("%s/%d", strategy, size), func(b *testing.B) {
+				c, _ := NewCollator(WithCollatorCompareOptions(WithStrategy(strategy)))
+				data := make([]string, size)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					copy(data, dataset)
+					c.Sort(data)
+				}
+			})
+		}
+	}
+}